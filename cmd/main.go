@@ -1,14 +1,17 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 
+	attachmentsutils "webtasksplannerexample/internal/attachments"
 	dbutils "webtasksplannerexample/internal/db"
 	models "webtasksplannerexample/internal/models"
 	webserverutils "webtasksplannerexample/internal/webserver"
@@ -18,27 +21,52 @@ const (
 	defaultHTTPPort   = 7540
 	defaultHTTPWebDir = `web`
 	defaultDBFilePath = `dbdata/scheduler.db`
+
+	defaultTrashRetentionDays = 30
+	defaultUndoWindowMinutes  = 15
+	trashPurgeInterval        = time.Hour
+
+	defaultAttachmentsDirName = `attachments`
+	defaultAttachmentMaxMB    = 10
 )
 
 func main() {
 	var (
-		err error
-		db  *sql.DB
+		err   error
+		store *dbutils.SQLStore
 	)
 
 	log.Println("Старт приложения")
 
 	serviceConfig := initServiceConfig()
 
-	db, err = dbutils.InitDB(serviceConfig.DbFilePath)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err = runMigrateCommand(serviceConfig, os.Args[2:]); err != nil {
+			log.Fatal("Ошибка выполнения миграций:", err)
+		}
+		return
+	}
+
+	store, err = dbutils.OpenStore(serviceConfig)
 	if err != nil {
 		log.Fatal("Ошибка инициализации БД:", err)
 	}
-	defer db.Close()
+	defer store.Close()
+
+	// Приводим схему базы данных к актуальной версии
+	if _, err = store.Migrate(); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+
+	// Фоновая очистка корзины от задач старше срока хранения вместе с файлами их вложений
+	files := attachmentsutils.NewStorage(serviceConfig.AttachmentsDir, serviceConfig.AttachmentMax)
+	go dbutils.RunTrashPurge(context.Background(), store, serviceConfig.TrashRetention, trashPurgeInterval, func() {
+		attachmentsutils.RemoveOrphans(store, files)
+	})
 
 	log.Println("Запуск web-сервера на порту [", serviceConfig.HTTPServerPort, "]...")
 
-	err = webserverutils.InitWebServer(serviceConfig)
+	err = webserverutils.InitWebServer(serviceConfig, store)
 	if err != nil {
 		log.Fatal("Ошибка запуска web-сервера:", err)
 	}
@@ -56,8 +84,13 @@ func initServiceConfig() models.ServiceConfig {
 	}
 
 	envDBFilePath := os.Getenv("TODO_DBFILE")
+	envDBDSN := os.Getenv("TODO_DB_DSN")
 	envHttpPort := os.Getenv("TODO_PORT")
 	envHttpWebDir := os.Getenv("TODO_WEBDIR")
+	envTrashRetention := os.Getenv("TODO_TRASH_RETENTION_DAYS")
+	envUndoWindow := os.Getenv("TODO_UNDO_WINDOW_MINUTES")
+	envAttachmentsDir := os.Getenv("TODO_ATTACHMENTS_DIR")
+	envAttachmentMax := os.Getenv("TODO_ATTACHMENT_MAX_MB")
 
 	workDir, err := os.Getwd()
 	if err != nil {
@@ -80,9 +113,81 @@ func initServiceConfig() models.ServiceConfig {
 		envHttpWebDir = filepath.Join(workDir, defaultHTTPWebDir)
 	}
 
+	iTrashRetentionDays := defaultTrashRetentionDays
+	if edays, err := strconv.Atoi(envTrashRetention); err == nil && edays >= 0 {
+		iTrashRetentionDays = edays
+	}
+
+	iUndoWindowMinutes := defaultUndoWindowMinutes
+	if eminutes, err := strconv.Atoi(envUndoWindow); err == nil && eminutes > 0 {
+		iUndoWindowMinutes = eminutes
+	}
+
+	// По умолчанию вложения хранятся рядом с файлом базы данных
+	strAttachmentsDir := filepath.Join(filepath.Dir(strDBPath), defaultAttachmentsDirName)
+	if envAttachmentsDir != "" {
+		strAttachmentsDir = filepath.Join(workDir, envAttachmentsDir)
+	}
+
+	iAttachmentMaxMB := defaultAttachmentMaxMB
+	if emb, err := strconv.Atoi(envAttachmentMax); err == nil && emb > 0 {
+		iAttachmentMaxMB = emb
+	}
+
 	s.DbFilePath = strDBPath
+	s.DbDSN = envDBDSN
 	s.HTTPServerPort = iHttpport
 	s.HTTPWebDir = envHttpWebDir
+	s.TrashRetention = time.Duration(iTrashRetentionDays) * 24 * time.Hour
+	s.UndoWindow = time.Duration(iUndoWindowMinutes) * time.Minute
+	s.AttachmentsDir = strAttachmentsDir
+	s.AttachmentMax = int64(iAttachmentMaxMB) << 20
 
 	return s
 }
+
+// runMigrateCommand обрабатывает подкоманду migrate:
+// migrate [up] - применить недостающие миграции, migrate status - показать состояние миграций.
+// Веб-сервер при этом не запускается.
+func runMigrateCommand(conf models.ServiceConfig, args []string) error {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	store, err := dbutils.OpenStore(conf)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch action {
+	case "up":
+		applied, err := store.Migrate()
+		for _, m := range applied {
+			log.Printf("Применена миграция %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("Схема базы данных актуальна, миграций для применения нет")
+		}
+	case "status":
+		statuses, err := store.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "ожидает"
+			if st.Applied {
+				state = "применена " + st.AppliedAt
+			}
+			fmt.Printf("%04d_%s\t%s\n", st.Version, st.Name, state)
+		}
+	default:
+		return fmt.Errorf("неизвестное действие %q, допустимые значения: up, status", action)
+	}
+
+	return nil
+}
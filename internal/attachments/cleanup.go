@@ -0,0 +1,23 @@
+package attachmentsutils
+
+import (
+	"log"
+
+	dbutils "webtasksplannerexample/internal/db"
+)
+
+// RemoveOrphans удаляет вложения задач, окончательно удалённых из корзины: сначала записи в базе, затем файлы
+func RemoveOrphans(store dbutils.AttachmentStore, storage *Storage) {
+	orphans, err := store.PurgeOrphanAttachments()
+	if err != nil {
+		log.Println("Ошибка очистки вложений:", err)
+		return
+	}
+
+	for _, attachment := range orphans {
+		storage.Remove(attachment.StorageName)
+	}
+	if len(orphans) > 0 {
+		log.Printf("Удалено вложений окончательно удалённых задач: %d", len(orphans))
+	}
+}
@@ -0,0 +1,125 @@
+package attachmentsutils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	models "webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrTooLarge = errors.New("файл превышает допустимый размер")
+	ErrEmpty    = errors.New("файл пуст")
+)
+
+// sniffLen - сколько первых байт файла использует http.DetectContentType
+const sniffLen = 512
+
+// Storage хранит содержимое вложений файлами в каталоге dir под случайными именами,
+// исходное имя файла и тип содержимого хранятся в базе данных
+type Storage struct {
+	dir     string
+	maxSize int64
+}
+
+func NewStorage(dir string, maxSize int64) *Storage {
+	return &Storage{dir: dir, maxSize: maxSize}
+}
+
+func (s *Storage) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save записывает содержимое r в новый файл хранилища и возвращает вложение с заполненными
+// StorageName, Size и ContentType. Тип определяется по содержимому, а если он не распознан - по расширению fileName.
+// При превышении максимального размера возвращает ErrTooLarge, файл при этом не сохраняется.
+func (s *Storage) Save(r io.Reader, fileName string) (models.Attachment, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return models.Attachment{}, fmt.Errorf("не удалось создать каталог вложений: %w", err)
+	}
+
+	storageName, err := newStorageName()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	f, err := os.OpenFile(s.path(storageName), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		f.Close()
+		s.Remove(storageName)
+		return models.Attachment{}, err
+	}
+	head = head[:n]
+
+	// Читаем на байт больше лимита, чтобы отличить файл ровно допустимого размера от слишком большого
+	size, err := io.Copy(f, io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.maxSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > s.maxSize {
+		err = ErrTooLarge
+	}
+	if err == nil && size == 0 {
+		err = ErrEmpty
+	}
+	if err != nil {
+		s.Remove(storageName)
+		return models.Attachment{}, err
+	}
+
+	return models.Attachment{
+		FileName:    fileName,
+		ContentType: detectContentType(head, fileName),
+		Size:        size,
+		StorageName: storageName,
+	}, nil
+}
+
+// Open открывает содержимое вложения для чтения
+func (s *Storage) Open(storageName string) (*os.File, error) {
+	return os.Open(s.path(storageName))
+}
+
+// Remove удаляет содержимое вложения, отсутствие файла ошибкой не считается
+func (s *Storage) Remove(storageName string) {
+	if err := os.Remove(s.path(storageName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Ошибка удаления файла вложения %s: %v", storageName, err)
+	}
+}
+
+func (s *Storage) path(storageName string) string {
+	return filepath.Join(s.dir, filepath.Base(storageName))
+}
+
+func newStorageName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func detectContentType(head []byte, fileName string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(fileName)); byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
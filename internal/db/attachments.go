@@ -0,0 +1,166 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("вложение не найдено")
+)
+
+// AttachmentStore описывает хранилище сведений о вложениях задач. Сами файлы хранятся отдельно,
+// поэтому при удалении записей вызывающая сторона удаляет и файлы возвращённых вложений.
+type AttachmentStore interface {
+	// AddAttachment сохраняет сведения о вложении задачи и возвращает его идентификатор
+	AddAttachment(attachment models.Attachment) (int64, error)
+	// ListAttachments возвращает вложения задачи в порядке добавления
+	ListAttachments(taskID string) ([]models.Attachment, error)
+	// GetAttachment возвращает вложение задачи, не находящейся в корзине, или ErrAttachmentNotFound
+	GetAttachment(id string) (models.Attachment, error)
+	// DeleteAttachment удаляет сведения о вложении и возвращает удалённое вложение
+	DeleteAttachment(id string) (models.Attachment, error)
+	// PurgeOrphanAttachments удаляет сведения о вложениях окончательно удалённых задач и возвращает их
+	PurgeOrphanAttachments() ([]models.Attachment, error)
+}
+
+const attachmentColumns = "id, task_id, file_name, content_type, size, storage_name, created_at"
+
+func scanAttachment(row rowScanner) (models.Attachment, error) {
+	var (
+		attachment models.Attachment
+		id         int64
+		taskID     int64
+	)
+	if err := row.Scan(&id, &taskID, &attachment.FileName, &attachment.ContentType, &attachment.Size,
+		&attachment.StorageName, &attachment.CreatedAt); err != nil {
+		return models.Attachment{}, err
+	}
+	attachment.ID = strconv.FormatInt(id, 10)
+	attachment.TaskID = strconv.FormatInt(taskID, 10)
+	return attachment, nil
+}
+
+func scanAttachments(rows *sql.Rows) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (s *SQLStore) AddAttachment(attachment models.Attachment) (int64, error) {
+	var id int64
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, attachment.TaskID); err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(s.q(`INSERT INTO attachments (task_id, file_name, content_type, size, storage_name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id`),
+		attachment.TaskID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.StorageName,
+		nowTimestamp(),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *SQLStore) ListAttachments(taskID string) ([]models.Attachment, error) {
+	if _, err := s.getTask(s.db, taskID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(s.q(`SELECT `+attachmentColumns+` FROM attachments WHERE task_id = ? ORDER BY id ASC`), taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+func (s *SQLStore) GetAttachment(id string) (models.Attachment, error) {
+	return s.getAttachment(s.db, id)
+}
+
+func (s *SQLStore) getAttachment(q dbtx, id string) (models.Attachment, error) {
+	attachment, err := scanAttachment(q.QueryRow(s.q(`SELECT `+attachmentColumns+` FROM attachments
+		WHERE id = ? AND task_id IN (SELECT id FROM scheduler WHERE deleted_at IS NULL)`), id))
+	if err == sql.ErrNoRows {
+		return models.Attachment{}, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+func (s *SQLStore) DeleteAttachment(id string) (models.Attachment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	defer tx.Rollback()
+
+	attachment, err := s.getAttachment(tx, id)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	if _, err = tx.Exec(s.q(`DELETE FROM attachments WHERE id = ?`), id); err != nil {
+		return models.Attachment{}, err
+	}
+
+	return attachment, tx.Commit()
+}
+
+func (s *SQLStore) PurgeOrphanAttachments() ([]models.Attachment, error) {
+	const orphans = `task_id NOT IN (SELECT id FROM scheduler)`
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(s.q(`SELECT ` + attachmentColumns + ` FROM attachments WHERE ` + orphans))
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := scanAttachments(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attachments) == 0 {
+		return attachments, nil
+	}
+
+	if _, err = tx.Exec(s.q(`DELETE FROM attachments WHERE ` + orphans)); err != nil {
+		return nil, err
+	}
+
+	return attachments, tx.Commit()
+}
@@ -0,0 +1,138 @@
+package dbutils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"webtasksplannerexample/internal/models"
+)
+
+const (
+	maxAuditRowCountLimit int = 500
+)
+
+// AuditStore описывает журнал изменений задач
+type AuditStore interface {
+	// AddAuditEntry сохраняет запись журнала, время записи проставляется хранилищем
+	AddAuditEntry(entry models.AuditEntry) error
+	// ListAuditEntries возвращает записи журнала по фильтру, начиная с последних
+	ListAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+func (s *SQLStore) AddAuditEntry(entry models.AuditEntry) error {
+	before, err := marshalTaskSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalTaskSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(s.q(`INSERT INTO audit_log (task_id, actor, action, before_data, after_data, request_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		entry.TaskID,
+		entry.Actor,
+		entry.Action,
+		before,
+		after,
+		entry.RequestID,
+		nowTimestamp(),
+	)
+	return err
+}
+
+func (s *SQLStore) ListAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	where := []string{"1 = 1"}
+	args := []any{}
+
+	if filter.TaskID != "" {
+		where = append(where, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.From != "" {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	limit := filter.Limit
+	switch {
+	case limit <= 0:
+		limit = maxRowCountLimit
+	case limit > maxAuditRowCountLimit:
+		limit = maxAuditRowCountLimit
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(s.q(`
+		SELECT id, task_id, actor, action, before_data, after_data, request_id, created_at
+		FROM audit_log WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY created_at DESC, id DESC LIMIT ?`),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry                    models.AuditEntry
+			before, after, requestID sql.NullString
+		)
+		if err := rows.Scan(&entry.ID, &entry.TaskID, &entry.Actor, &entry.Action,
+			&before, &after, &requestID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if entry.Before, err = unmarshalTaskSnapshot(before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = unmarshalTaskSnapshot(after); err != nil {
+			return nil, err
+		}
+		entry.RequestID = requestID.String
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// marshalTaskSnapshot сериализует состояние задачи в JSON, отсутствующее состояние хранится как NULL
+func marshalTaskSnapshot(task *models.FullTask) (sql.NullString, error) {
+	if task == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalTaskSnapshot(data sql.NullString) (*models.FullTask, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+	var task models.FullTask
+	if err := json.Unmarshal([]byte(data.String), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
@@ -0,0 +1,248 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrStatusNotAllowed = errors.New("статуса нет среди колонок доски проекта")
+)
+
+// Названия колонок доски, если они не настроены для проекта
+var defaultColumnTitles = map[string]string{
+	models.TaskStatusTodo:       "К выполнению",
+	models.TaskStatusInProgress: "В работе",
+	models.TaskStatusWaiting:    "Ожидание",
+	models.TaskStatusDone:       "Готово",
+}
+
+// BoardStore описывает доску задач: колонки статусов проектов и порядок задач в них.
+// Доска без проекта (projectID = "") содержит задачи, не относящиеся ни к одному проекту.
+type BoardStore interface {
+	// ListColumns возвращает колонки доски проекта, без настройки - все статусы по порядку
+	ListColumns(projectID string) ([]models.ProjectColumn, error)
+	// SetColumns задаёт колонки доски проекта, пустой список возвращает колонки по умолчанию
+	SetColumns(projectID string, columns []models.ProjectColumn) error
+	// Board возвращает действующие задачи проекта, разложенные по колонкам.
+	// Задачи со статусом, для которого у проекта нет колонки, попадают в конец первой колонки.
+	Board(projectID string) (models.Board, error)
+	// MoveTask переносит задачу в колонку статуса move.Status на позицию move.Position
+	MoveTask(id string, move models.TaskMove) error
+}
+
+func defaultColumns() []models.ProjectColumn {
+	columns := make([]models.ProjectColumn, 0, len(models.TaskStatuses))
+	for _, status := range models.TaskStatuses {
+		columns = append(columns, models.ProjectColumn{Status: status, Title: defaultColumnTitles[status]})
+	}
+	return columns
+}
+
+func (s *SQLStore) ListColumns(projectID string) ([]models.ProjectColumn, error) {
+	if projectID != "" {
+		if _, err := s.GetProject(projectID); err != nil {
+			return nil, err
+		}
+	}
+	return s.listColumns(s.db, projectID)
+}
+
+func (s *SQLStore) listColumns(q dbtx, projectID string) ([]models.ProjectColumn, error) {
+	if projectID == "" {
+		return defaultColumns(), nil
+	}
+
+	rows, err := q.Query(s.q(`SELECT status, title FROM project_columns WHERE project_id = ? ORDER BY position ASC`), projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []models.ProjectColumn{}
+	for rows.Next() {
+		var column models.ProjectColumn
+		if err := rows.Scan(&column.Status, &column.Title); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return defaultColumns(), nil
+	}
+	return columns, nil
+}
+
+func (s *SQLStore) SetColumns(projectID string, columns []models.ProjectColumn) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err = tx.QueryRow(s.q(`SELECT id FROM projects WHERE id = ?`), projectID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	if _, err = tx.Exec(s.q(`DELETE FROM project_columns WHERE project_id = ?`), projectID); err != nil {
+		return err
+	}
+
+	for i, column := range columns {
+		if column.Title == "" {
+			column.Title = defaultColumnTitles[column.Status]
+		}
+		if _, err = tx.Exec(s.q(`INSERT INTO project_columns (project_id, status, title, position) VALUES (?, ?, ?, ?)`),
+			projectID, column.Status, column.Title, i+1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) Board(projectID string) (models.Board, error) {
+	columns, err := s.ListColumns(projectID)
+	if err != nil {
+		return models.Board{}, err
+	}
+
+	where, args := boardScope(projectID)
+	rows, err := s.db.Query(s.q(`SELECT `+taskColumns+` FROM scheduler
+		WHERE deleted_at IS NULL AND `+where+`
+		ORDER BY position ASC, id ASC`), args...)
+	if err != nil {
+		return models.Board{}, err
+	}
+	defer rows.Close()
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return models.Board{}, err
+	}
+	if err = s.loadTags(s.db, tasks); err != nil {
+		return models.Board{}, err
+	}
+	if err = s.loadBlockers(s.db, tasks); err != nil {
+		return models.Board{}, err
+	}
+	if err = s.loadCommentCounts(s.db, tasks); err != nil {
+		return models.Board{}, err
+	}
+
+	board := models.Board{Columns: make([]models.BoardColumn, 0, len(columns))}
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		board.Columns = append(board.Columns, models.BoardColumn{ProjectColumn: column, Tasks: []models.FullTask{}})
+		index[column.Status] = i
+	}
+	orphans := []models.FullTask{}
+	for _, task := range tasks {
+		i, ok := index[task.Status]
+		if !ok {
+			orphans = append(orphans, task)
+			continue
+		}
+		board.Columns[i].Tasks = append(board.Columns[i].Tasks, task)
+	}
+	board.Columns[0].Tasks = append(board.Columns[0].Tasks, orphans...)
+
+	return board, nil
+}
+
+// boardScope возвращает условие отбора задач доски проекта projectID
+func boardScope(projectID any) (string, []any) {
+	if projectID == nil || projectID == "" {
+		return "project_id IS NULL", nil
+	}
+	return "project_id = ?", []any{projectID}
+}
+
+// nextPosition возвращает позицию в конце колонки статуса status на доске проекта projectID
+func (s *SQLStore) nextPosition(q dbtx, projectID any, status string) (int, error) {
+	var position int
+
+	where, args := boardScope(projectID)
+	err := q.QueryRow(s.q(`SELECT COALESCE(MAX(position), 0) FROM scheduler
+		WHERE deleted_at IS NULL AND status = ? AND `+where),
+		append([]any{status}, args...)...,
+	).Scan(&position)
+
+	return position + 1, err
+}
+
+func (s *SQLStore) MoveTask(id string, move models.TaskMove) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	task, err := s.getTask(tx, id)
+	if err != nil {
+		return err
+	}
+
+	projectID := ""
+	if task.ProjectID != nil {
+		projectID = *task.ProjectID
+	}
+	columns, err := s.listColumns(tx, projectID)
+	if err != nil {
+		return err
+	}
+	allowed := false
+	for _, column := range columns {
+		allowed = allowed || column.Status == move.Status
+	}
+	if !allowed {
+		return ErrStatusNotAllowed
+	}
+
+	where, args := boardScope(projectID)
+	rows, err := tx.Query(s.q(`SELECT id FROM scheduler
+		WHERE deleted_at IS NULL AND status = ? AND id <> ? AND `+where+`
+		ORDER BY position ASC, id ASC`),
+		append([]any{move.Status, id}, args...)...,
+	)
+	if err != nil {
+		return err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var columnTaskID int64
+		if err := rows.Scan(&columnTaskID); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, strconv.FormatInt(columnTaskID, 10))
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	position := min(max(move.Position, 0), len(ids))
+	ids = append(ids[:position], append([]string{id}, ids[position:]...)...)
+
+	// Позиции всей колонки пересчитываются, чтобы порядок не зависел от прежних значений
+	for i, columnTaskID := range ids {
+		if _, err = tx.Exec(s.q(`UPDATE scheduler SET status = ?, position = ? WHERE id = ?`),
+			move.Status, i+1, columnTaskID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,133 @@
+package dbutils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+var (
+	ErrTaskBlocked     = errors.New("задача заблокирована невыполненными задачами")
+	ErrBulkRolledBack  = errors.New("операция отменена из-за ошибки в другой операции пакета")
+	ErrBulkUnsupported = errors.New("неизвестная операция")
+)
+
+// BulkOutcome - результат операции пакета: ошибка (nil - выполнена) и задача до и после выполненной операции.
+// Задача читается в транзакции пакета, поэтому у нескольких операций над одной задачей Before каждой
+// совпадает с After предыдущей. After - nil, если после операции задачи нет (удалена или выполнена).
+type BulkOutcome struct {
+	Err    error
+	Before *models.FullTask
+	After  *models.FullTask
+}
+
+// BulkStore описывает пакетную обработку задач
+type BulkStore interface {
+	// ApplyBulk выполняет операции в одной транзакции и возвращает результат каждой операции.
+	// При atomic ошибка любой операции отменяет весь пакет, остальные операции получают ErrBulkRolledBack.
+	// Возвращаемая ошибка означает сбой самого хранилища.
+	ApplyBulk(ops []models.BulkOperation, now time.Time, atomic bool) ([]BulkOutcome, error)
+}
+
+func (s *SQLStore) ApplyBulk(ops []models.BulkOperation, now time.Time, atomic bool) ([]BulkOutcome, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]BulkOutcome, len(ops))
+	failed := false
+	for i, op := range ops {
+		// Точка сохранения позволяет откатить только неудачную операцию:
+		// в PostgreSQL после ошибки без неё не выполнится ни один запрос транзакции
+		if _, err = tx.Exec(`SAVEPOINT bulk_op`); err != nil {
+			return nil, err
+		}
+
+		results[i].Before, results[i].Err = s.applyBulkOperation(tx, op, now)
+		if results[i].Err != nil {
+			results[i].Before = nil
+			failed = true
+			if _, err = tx.Exec(`ROLLBACK TO SAVEPOINT bulk_op`); err != nil {
+				return nil, err
+			}
+			if atomic {
+				break
+			}
+		}
+
+		if _, err = tx.Exec(`RELEASE SAVEPOINT bulk_op`); err != nil {
+			return nil, err
+		}
+
+		if results[i].Err == nil {
+			if task, err := s.getTask(tx, op.ID); err == nil {
+				results[i].After = &task
+			}
+		}
+	}
+
+	if atomic && failed {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BulkOutcome{Err: ErrBulkRolledBack}
+			}
+		}
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// applyBulkOperation выполняет одну операцию пакета в рамках транзакции q и возвращает задачу до операции
+func (s *SQLStore) applyBulkOperation(q dbtx, op models.BulkOperation, now time.Time) (*models.FullTask, error) {
+	task, err := s.getTask(q, op.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case models.BulkOpDone:
+		if len(task.BlockedBy) > 0 && !op.Force {
+			return nil, ErrTaskBlocked
+		}
+		return &task, s.completeTask(q, task, now)
+
+	case models.BulkOpDelete:
+		return &task, s.deleteTask(q, op.ID)
+
+	case models.BulkOpMove:
+		var date string
+		if op.By != "" {
+			date, err = utils.SnoozeDate(now, task.Date, op.By)
+		} else {
+			date, err = utils.NormalizeDate(now, op.Date, task.Repeat)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &task, s.rescheduleTask(q, op.ID, date)
+
+	case models.BulkOpTag:
+		if slices.Contains(task.Tags, op.Tag) {
+			return &task, nil
+		}
+		tags, err := utils.NormalizeTags(append(slices.Clone(task.Tags), op.Tag))
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseInt(op.ID, 10, 64)
+		if err != nil {
+			return nil, ErrTaskNotFound
+		}
+		return &task, s.setTaskTags(q, id, tags)
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrBulkUnsupported, op.Op)
+}
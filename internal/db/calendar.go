@@ -0,0 +1,48 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrCalendarTokenNotFound = errors.New("токен календаря не найден")
+)
+
+// CalendarStore описывает хранилище токенов календарных подписок. Токены хранятся в виде хешей,
+// у каждого пользователя не больше одного токена.
+type CalendarStore interface {
+	// SetCalendarToken сохраняет хеш нового токена пользователя actor, заменяя прежний
+	SetCalendarToken(actor, tokenHash string) error
+	// DeleteCalendarToken отзывает токен пользователя actor или возвращает ErrCalendarTokenNotFound
+	DeleteCalendarToken(actor string) error
+	// CalendarTokenActor возвращает пользователя, которому выдан токен с хешем tokenHash, или ErrCalendarTokenNotFound
+	CalendarTokenActor(tokenHash string) (string, error)
+}
+
+func (s *SQLStore) SetCalendarToken(actor, tokenHash string) error {
+	_, err := s.db.Exec(s.q(`
+		INSERT INTO calendar_tokens (actor, token_hash, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (actor) DO UPDATE SET token_hash = excluded.token_hash, created_at = excluded.created_at`),
+		actor, tokenHash, nowTimestamp(),
+	)
+	return err
+}
+
+func (s *SQLStore) DeleteCalendarToken(actor string) error {
+	result, err := s.db.Exec(s.q(`DELETE FROM calendar_tokens WHERE actor = ?`), actor)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result, ErrCalendarTokenNotFound)
+}
+
+func (s *SQLStore) CalendarTokenActor(tokenHash string) (string, error) {
+	var actor string
+
+	err := s.db.QueryRow(s.q(`SELECT actor FROM calendar_tokens WHERE token_hash = ?`), tokenHash).Scan(&actor)
+	if err == sql.ErrNoRows {
+		return "", ErrCalendarTokenNotFound
+	}
+	return actor, err
+}
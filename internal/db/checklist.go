@@ -0,0 +1,221 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrChecklistItemNotFound = errors.New("пункт чек-листа не найден")
+	ErrChecklistMismatch     = errors.New("список пунктов не совпадает с чек-листом задачи")
+)
+
+// ChecklistStore описывает хранилище чек-листов задач
+type ChecklistStore interface {
+	// ListChecklist возвращает пункты чек-листа задачи по порядку
+	ListChecklist(taskID string) ([]models.ChecklistItem, error)
+	// AddChecklistItem добавляет пункт в конец чек-листа задачи и возвращает его идентификатор
+	AddChecklistItem(taskID string, title string) (int64, error)
+	// ToggleChecklistItem меняет отметку о выполнении пункта и возвращает пункт после изменения
+	ToggleChecklistItem(id string) (models.ChecklistItem, error)
+	// ReorderChecklist расставляет пункты в порядке ids, в ids должны быть все пункты чек-листа
+	ReorderChecklist(taskID string, ids []string) error
+	// DeleteChecklistItem удаляет пункт чек-листа
+	DeleteChecklistItem(id string) error
+}
+
+const checklistColumns = "id, task_id, title, done, position"
+
+func scanChecklistItem(row rowScanner) (models.ChecklistItem, error) {
+	var (
+		item   models.ChecklistItem
+		id     int64
+		taskID int64
+	)
+	if err := row.Scan(&id, &taskID, &item.Title, &item.Done, &item.Position); err != nil {
+		return models.ChecklistItem{}, err
+	}
+	item.ID = strconv.FormatInt(id, 10)
+	item.TaskID = strconv.FormatInt(taskID, 10)
+	return item, nil
+}
+
+func (s *SQLStore) ListChecklist(taskID string) ([]models.ChecklistItem, error) {
+	if _, err := s.getTask(s.db, taskID); err != nil {
+		return nil, err
+	}
+	return s.loadChecklist(s.db, taskID)
+}
+
+// loadChecklist возвращает пункты чек-листа задачи, не проверяя существование задачи
+func (s *SQLStore) loadChecklist(q dbtx, taskID string) ([]models.ChecklistItem, error) {
+	rows, err := q.Query(s.q(`SELECT `+checklistColumns+` FROM checklist_items
+		WHERE task_id = ? ORDER BY position ASC, id ASC`), taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *SQLStore) AddChecklistItem(taskID string, title string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, taskID); err != nil {
+		return 0, err
+	}
+
+	id, err := s.addChecklistItem(tx, taskID, title)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// addChecklistItem добавляет пункт в конец чек-листа задачи taskID в рамках транзакции q
+func (s *SQLStore) addChecklistItem(q dbtx, taskID string, title string) (int64, error) {
+	var (
+		id       int64
+		position int
+	)
+
+	if err := q.QueryRow(s.q(`SELECT COALESCE(MAX(position), 0) FROM checklist_items WHERE task_id = ?`),
+		taskID).Scan(&position); err != nil {
+		return 0, err
+	}
+
+	err := q.QueryRow(s.q(
+		"INSERT INTO checklist_items (task_id, title, done, position) VALUES (?, ?, ?, ?) RETURNING id"),
+		taskID,
+		title,
+		false,
+		position+1,
+	).Scan(&id)
+	return id, err
+}
+
+// getChecklistItem возвращает пункт чек-листа задачи, не находящейся в корзине
+func (s *SQLStore) getChecklistItem(q dbtx, id string) (models.ChecklistItem, error) {
+	item, err := scanChecklistItem(q.QueryRow(s.q(`SELECT `+checklistColumns+` FROM checklist_items
+		WHERE id = ? AND task_id IN (SELECT id FROM scheduler WHERE deleted_at IS NULL)`), id))
+	if err == sql.ErrNoRows {
+		return models.ChecklistItem{}, ErrChecklistItemNotFound
+	}
+	return item, err
+}
+
+func (s *SQLStore) ToggleChecklistItem(id string) (models.ChecklistItem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+	defer tx.Rollback()
+
+	item, err := s.getChecklistItem(tx, id)
+	if err != nil {
+		return models.ChecklistItem{}, err
+	}
+
+	item.Done = !item.Done
+	if _, err = tx.Exec(s.q(`UPDATE checklist_items SET done = ? WHERE id = ?`), item.Done, id); err != nil {
+		return models.ChecklistItem{}, err
+	}
+
+	return item, tx.Commit()
+}
+
+func (s *SQLStore) ReorderChecklist(taskID string, ids []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, taskID); err != nil {
+		return err
+	}
+
+	items, err := s.loadChecklist(tx, taskID)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != len(items) {
+		return ErrChecklistMismatch
+	}
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.ID] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return ErrChecklistMismatch
+		}
+		delete(known, id)
+	}
+
+	for i, id := range ids {
+		if _, err = tx.Exec(s.q(`UPDATE checklist_items SET position = ? WHERE id = ?`), i+1, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteChecklistItem(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getChecklistItem(tx, id); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(s.q(`DELETE FROM checklist_items WHERE id = ?`), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resetChecklist снимает отметки о выполнении со всех пунктов чек-листа задачи
+func (s *SQLStore) resetChecklist(q dbtx, taskID string) error {
+	_, err := q.Exec(s.q(`UPDATE checklist_items SET done = ? WHERE task_id = ?`), false, taskID)
+	return err
+}
+
+// restoreChecklist возвращает пунктам чек-листа отметки о выполнении из снимка задачи.
+// Пункты, удалённые после снимка, не восстанавливаются.
+func (s *SQLStore) restoreChecklist(q dbtx, taskID string, items []models.ChecklistItem) error {
+	for _, item := range items {
+		if _, err := q.Exec(s.q(`UPDATE checklist_items SET done = ? WHERE id = ? AND task_id = ?`),
+			item.Done, item.ID, taskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
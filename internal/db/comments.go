@@ -0,0 +1,173 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrCommentNotFound = errors.New("комментарий не найден")
+)
+
+// CommentStore описывает хранилище обсуждений задач
+type CommentStore interface {
+	// ListComments возвращает комментарии задачи в порядке добавления
+	ListComments(taskID string) ([]models.TaskComment, error)
+	// AddComment сохраняет комментарий к задаче и возвращает его идентификатор
+	AddComment(comment models.TaskComment) (int64, error)
+	// GetComment возвращает комментарий к задаче, не находящейся в корзине, или ErrCommentNotFound
+	GetComment(id string) (models.TaskComment, error)
+	// UpdateComment заменяет текст комментария и отмечает время изменения
+	UpdateComment(id string, text string) error
+	// DeleteComment удаляет комментарий
+	DeleteComment(id string) error
+}
+
+const commentColumns = "id, task_id, author, text, created_at, updated_at"
+
+func scanComment(row rowScanner) (models.TaskComment, error) {
+	var (
+		comment   models.TaskComment
+		id        int64
+		taskID    int64
+		updatedAt sql.NullString
+	)
+	if err := row.Scan(&id, &taskID, &comment.Author, &comment.Text, &comment.CreatedAt, &updatedAt); err != nil {
+		return models.TaskComment{}, err
+	}
+	comment.ID = strconv.FormatInt(id, 10)
+	comment.TaskID = strconv.FormatInt(taskID, 10)
+	comment.UpdatedAt = updatedAt.String
+	return comment, nil
+}
+
+func (s *SQLStore) ListComments(taskID string) ([]models.TaskComment, error) {
+	if _, err := s.getTask(s.db, taskID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(s.q(`SELECT `+commentColumns+` FROM task_comments WHERE task_id = ? ORDER BY id ASC`), taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.TaskComment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (s *SQLStore) AddComment(comment models.TaskComment) (int64, error) {
+	var id int64
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, comment.TaskID); err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(s.q(`INSERT INTO task_comments (task_id, author, text, created_at) VALUES (?, ?, ?, ?) RETURNING id`),
+		comment.TaskID,
+		comment.Author,
+		comment.Text,
+		nowTimestamp(),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *SQLStore) GetComment(id string) (models.TaskComment, error) {
+	comment, err := scanComment(s.db.QueryRow(s.q(`SELECT `+commentColumns+` FROM task_comments
+		WHERE id = ? AND task_id IN (SELECT id FROM scheduler WHERE deleted_at IS NULL)`), id))
+	if err == sql.ErrNoRows {
+		return models.TaskComment{}, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+func (s *SQLStore) UpdateComment(id string, text string) error {
+	result, err := s.db.Exec(s.q(`UPDATE task_comments SET text = ?, updated_at = ?
+		WHERE id = ? AND task_id IN (SELECT id FROM scheduler WHERE deleted_at IS NULL)`),
+		text,
+		nowTimestamp(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrCommentNotFound)
+}
+
+func (s *SQLStore) DeleteComment(id string) error {
+	result, err := s.db.Exec(s.q(`DELETE FROM task_comments
+		WHERE id = ? AND task_id IN (SELECT id FROM scheduler WHERE deleted_at IS NULL)`), id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrCommentNotFound)
+}
+
+// loadCommentCounts заполняет у переданных задач количество комментариев одним запросом
+func (s *SQLStore) loadCommentCounts(q dbtx, tasks []models.FullTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+	}
+
+	rows, err := q.Query(s.q(`
+		SELECT task_id, COUNT(*) FROM task_comments
+		WHERE task_id IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY task_id`),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			taskID int64
+			count  int
+		)
+		if err := rows.Scan(&taskID, &count); err != nil {
+			return err
+		}
+		if i, ok := index[strconv.FormatInt(taskID, 10)]; ok {
+			tasks[i].CommentCount = count
+		}
+	}
+
+	return rows.Err()
+}
@@ -6,7 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
 	"webtasksplannerexample/internal/models"
+	"webtasksplannerexample/internal/utils"
 
 	_ "modernc.org/sqlite"
 )
@@ -15,10 +20,6 @@ const (
 	maxRowCountLimit int = 50
 )
 
-var (
-	db *sql.DB
-)
-
 func createDirPathIfNotExist(path string) error {
 	var (
 		err error
@@ -37,7 +38,12 @@ func createDirPathIfNotExist(path string) error {
 	return nil
 }
 
-func InitDB(dbFilePath string) (*sql.DB, error) {
+// Параметр подключения SQLite, включающий проверку внешних ключей: без него SQLite не выполняет
+// ограничения REFERENCES и ON DELETE CASCADE из миграций. Действует на каждое подключение пула.
+const sqliteForeignKeys = "_pragma=foreign_keys(1)"
+
+// OpenDB создаёт при необходимости файл базы данных и открывает подключение к нему без применения миграций
+func OpenDB(dbFilePath string) (*sql.DB, error) {
 	var err error
 
 	if err = createDirPathIfNotExist(dbFilePath); err != nil {
@@ -54,144 +60,497 @@ func InitDB(dbFilePath string) (*sql.DB, error) {
 	}
 
 	// Подключаемся к базе данных
-	db, err = sql.Open("sqlite", dbFilePath)
+	conn, err := sql.Open("sqlite", dbFilePath+"?"+sqliteForeignKeys)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось открыть базу данных: %w", err)
 	}
 
-	// Проверяем наличие таблицы 'scheduler' и создаем ее, если необходимо
-	if _, err = db.Exec(`CREATE TABLE IF NOT EXISTS scheduler (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        date VARCHAR(8) NOT NULL,
-        title VARCHAR(64) NOT NULL,
-        comment TEXT CHECK(length(comment) <= 512),
-        repeat  VARCHAR(128)
-    )`); err != nil {
-		return nil, fmt.Errorf("не удалось создать таблицу 'scheduler': %w", err)
+	return conn, nil
+}
+
+// SQLStore реализует TaskStore поверх SQL-базы данных.
+// Запросы общие для всех диалектов, различия в схеме вынесены в миграции.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+
+	keepAlive *sql.Conn // Удерживает базу в памяти, см. NewMemoryStore
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db, dialect: DialectSQLite}
+}
+
+func NewPostgresStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db, dialect: DialectPostgres}
+}
+
+func (s *SQLStore) Dialect() Dialect {
+	return s.dialect
+}
+
+// Migrate приводит схему базы данных к актуальной версии
+func (s *SQLStore) Migrate() ([]Migration, error) {
+	return Migrate(s.db, s.dialect)
+}
+
+func (s *SQLStore) MigrationStatus() ([]MigrationStatus, error) {
+	return GetMigrationStatus(s.db, s.dialect)
+}
+
+func (s *SQLStore) Close() error {
+	if s.keepAlive != nil {
+		s.keepAlive.Close()
 	}
+	return s.db.Close()
+}
 
-	// Создаём индекс по полю 'date'
-	if _, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_date ON scheduler(date)`); err != nil {
-		return nil, fmt.Errorf("не удалось создать индекс по полю 'date': %w", err)
+// q подставляет в запрос плейсхолдеры диалекта хранилища
+func (s *SQLStore) q(query string) string {
+	return s.dialect.rebind(query)
+}
+
+func (s *SQLStore) Add(task models.Task) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	return db, nil
+	id, err := s.addTask(tx, task)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func AddTask(task models.Task) (int64, error) {
-	result, err := db.Exec(
-		"INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, ?, ?)",
+// addTask сохраняет новую задачу с метками в рамках транзакции q
+func (s *SQLStore) addTask(q dbtx, task models.Task) (int64, error) {
+	var id int64
+
+	projectID, err := projectIDValue(task.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.checkProjectAssignable(q, projectID, nil); err != nil {
+		return 0, err
+	}
+
+	status := task.Status
+	if status == "" {
+		status = models.TaskStatusTodo
+	}
+	position, err := s.nextPosition(q, projectID, status)
+	if err != nil {
+		return 0, err
+	}
+
+	// RETURNING вместо LastInsertId: драйвер PostgreSQL не поддерживает LastInsertId
+	err = q.QueryRow(s.q(
+		`INSERT INTO scheduler (date, title, comment, repeat, project_id, priority, created_at, status, position, estimate_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
 		task.Date,
 		task.Title,
 		task.Comment,
 		task.Repeat,
-	)
+		projectID,
+		optionalInt(task.Priority),
+		nowTimestamp(),
+		status,
+		position,
+		optionalInt(task.Estimate),
+	).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
+	if err = s.setTaskTags(q, id, task.Tags); err != nil {
 		return 0, err
 	}
 
 	return id, nil
 }
 
-func GetTasks(searchString string, searchIsDate bool) ([]models.FullTask, error) {
-	var (
-		err  error
-		rows *sql.Rows
-	)
-	if searchString != "" && searchIsDate {
-		rows, err = db.Query(`
-			SELECT id, date, title, comment, repeat
-			FROM scheduler WHERE date = ?
-			ORDER BY date ASC LIMIT ?`,
-			searchString,
-			maxRowCountLimit,
-		)
-	} else if searchString != "" && !searchIsDate {
-		searchString = `%` + searchString + `%`
-		rows, err = db.Query(`
-			SELECT id, date, title, comment, repeat
-			FROM scheduler WHERE LOWER(title) LIKE LOWER(?) 
-			OR LOWER(comment) LIKE LOWER(?)
-			ORDER BY date ASC LIMIT ?`,
-			searchString,
-			searchString,
-			maxRowCountLimit,
-		)
-	} else {
-		rows, err = db.Query(`
-			SELECT id, date, title, comment, repeat
-			FROM scheduler
-			ORDER BY date ASC LIMIT ?`,
-			maxRowCountLimit,
-		)
+func (s *SQLStore) List(filter models.TaskFilter) ([]models.FullTask, error) {
+	// Задачи в корзине в общий список не попадают
+	where := []string{"deleted_at IS NULL"}
+	args := []any{}
+
+	if filter.Date != "" {
+		where = append(where, "date = ?")
+		args = append(args, filter.Date)
+	} else if filter.Search != "" {
+		searchString := `%` + filter.Search + `%`
+		where = append(where, "(LOWER(title) LIKE LOWER(?) OR LOWER(comment) LIKE LOWER(?))")
+		args = append(args, searchString, searchString)
+	}
+	if filter.Tag != "" {
+		where = append(where, `id IN (
+			SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name = ?)`)
+		args = append(args, filter.Tag)
+	}
+	if filter.ProjectID != "" {
+		where = append(where, "project_id = ?")
+		args = append(args, filter.ProjectID)
 	}
+	args = append(args, maxRowCountLimit)
 
+	orderBy, err := taskOrderBy(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(s.q(`
+		SELECT `+taskColumns+`
+		FROM scheduler WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+orderBy+` LIMIT ?`),
+		args...,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.loadTags(s.db, tasks); err != nil {
+		return nil, err
+	}
+	if err = s.loadCommentCounts(s.db, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, s.loadBlockers(s.db, tasks)
+}
+
+// Выражения сортировки для полей models.TaskSort.
+// Задачи без приоритета (0) при сортировке по возрастанию идут после задач с приоритетом 4.
+var taskSortColumns = map[string]string{
+	models.TaskSortDate:     "date",
+	models.TaskSortPriority: "CASE WHEN priority = 0 THEN 5 ELSE priority END",
+	models.TaskSortTitle:    "title",
+	models.TaskSortCreated:  "created_at",
+}
+
+// taskOrderBy возвращает условие ORDER BY для списка задач, при равенстве задачи упорядочиваются по id
+func taskOrderBy(sort models.TaskSort) (string, error) {
+	field := sort.Field
+	if field == "" {
+		field = models.TaskSortDate
+	}
+	column, ok := taskSortColumns[field]
+	if !ok {
+		return "", fmt.Errorf("неизвестное поле сортировки: %s", field)
+	}
+
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+	return column + " " + direction + ", id ASC", nil
+}
+
+// Поля задачи в порядке, ожидаемом scanTasks и getTask
+const taskColumns = "id, date, title, comment, repeat, deleted_at, project_id, priority, created_at, status, estimate_minutes"
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (models.FullTask, error) {
+	var (
+		task      models.FullTask
+		deletedAt sql.NullString
+		projectID sql.NullInt64
+		priority  int
+		estimate  int
+	)
+	if err := row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat, &deletedAt, &projectID,
+		&priority, &task.CreatedAt, &task.Status, &estimate); err != nil {
+		return models.FullTask{}, err
+	}
+	task.DeletedAt = deletedAt.String
+	if projectID.Valid {
+		id := strconv.FormatInt(projectID.Int64, 10)
+		task.ProjectID = &id
+	}
+	if priority != 0 {
+		task.Priority = &priority
+	}
+	if estimate != 0 {
+		task.Estimate = &estimate
+	}
+	return task, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]models.FullTask, error) {
 	tasks := []models.FullTask{}
 	for rows.Next() {
-		var task models.FullTask
-		if err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return tasks, nil
 }
 
-func GetTaskByID(id string) (models.FullTask, error) {
+func (s *SQLStore) Get(id string) (models.FullTask, error) {
+	return s.getTask(s.db, id)
+}
 
-	var task models.FullTask
+// dbtx позволяет выполнять одни и те же запросы как в транзакции, так и вне её
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// getTask возвращает задачу, не находящуюся в корзине
+func (s *SQLStore) getTask(q dbtx, id string) (models.FullTask, error) {
+	return s.scanTaskRow(q, q.QueryRow(s.q(`SELECT `+taskColumns+` FROM scheduler WHERE id = ? AND deleted_at IS NULL`), id))
+}
 
-	row := db.QueryRow(`SELECT id, date, title, comment, repeat FROM scheduler WHERE id = $1`, id)
+// getTaskAny возвращает задачу независимо от того, находится ли она в корзине
+func (s *SQLStore) getTaskAny(q dbtx, id string) (models.FullTask, error) {
+	return s.scanTaskRow(q, q.QueryRow(s.q(`SELECT `+taskColumns+` FROM scheduler WHERE id = ?`), id))
+}
 
-	err := row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+// scanTaskRow читает задачу из строки результата и дополняет её метками, чек-листом,
+// блокирующими и ждущими её задачами и количеством комментариев
+func (s *SQLStore) scanTaskRow(q dbtx, row *sql.Row) (models.FullTask, error) {
+	task, err := scanTask(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return models.FullTask{}, fmt.Errorf("задача не найдена")
+			return models.FullTask{}, ErrTaskNotFound
 		}
 		return models.FullTask{}, err
 	}
-	return task, nil
+
+	tasks := []models.FullTask{task}
+	if err = s.loadTags(q, tasks); err != nil {
+		return models.FullTask{}, err
+	}
+	if err = s.loadBlockers(q, tasks); err != nil {
+		return models.FullTask{}, err
+	}
+	if err = s.loadCommentCounts(q, tasks); err != nil {
+		return models.FullTask{}, err
+	}
+	if tasks[0].Checklist, err = s.loadChecklist(q, task.ID); err != nil {
+		return models.FullTask{}, err
+	}
+	if tasks[0].Blocks, err = s.loadDependents(q, task.ID); err != nil {
+		return models.FullTask{}, err
+	}
+	return tasks[0], nil
 }
 
-func UpdateTask(task models.FullTask) error {
+// Update перезаписывает поля задачи. Метки, проект, приоритет и оценка заменяются, только если соответствующие поля не nil,
+// статус - только если он не пустой.
+func (s *SQLStore) Update(task models.FullTask) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	_, err := db.Exec(`UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?`,
-		task.Date,
-		task.Title,
-		task.Comment,
-		task.Repeat,
-		task.ID,
+	current, err := s.getTask(tx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	set := "date = ?, title = ?, comment = ?, repeat = ?"
+	args := []any{task.Date, task.Title, task.Comment, task.Repeat}
+
+	if task.Priority != nil {
+		set += ", priority = ?"
+		args = append(args, *task.Priority)
+	}
+
+	if task.Estimate != nil {
+		set += ", estimate_minutes = ?"
+		args = append(args, *task.Estimate)
+	}
+
+	projectID, err := projectIDValue(current.ProjectID)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID != nil {
+		if projectID, err = projectIDValue(task.ProjectID); err != nil {
+			return err
+		}
+		if err = s.checkProjectAssignable(tx, projectID, current.ProjectID); err != nil {
+			return err
+		}
+		set += ", project_id = ?"
+		args = append(args, projectID)
+	}
+
+	// При смене статуса или проекта задача встаёт в конец своей колонки на доске
+	status := current.Status
+	if task.Status != "" {
+		status = task.Status
+	}
+	if status != current.Status || (task.ProjectID != nil && !sameProject(task.ProjectID, current.ProjectID)) {
+		position, err := s.nextPosition(tx, projectID, status)
+		if err != nil {
+			return err
+		}
+		set += ", status = ?, position = ?"
+		args = append(args, status, position)
+	}
+	args = append(args, task.ID)
+
+	result, err := tx.Exec(s.q(`UPDATE scheduler SET `+set+` WHERE id = ? AND deleted_at IS NULL`), args...)
+
+	if err != nil {
+		return err
+	}
+
+	if err = checkAffected(result); err != nil {
+		return err
+	}
+
+	if task.Tags != nil {
+		taskID, err := strconv.ParseInt(task.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err = s.setTaskTags(tx, taskID, task.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete перемещает задачу в корзину, окончательно она удаляется PurgeTrash
+func (s *SQLStore) Delete(id string) error {
+	return s.deleteTask(s.db, id)
+}
+
+func (s *SQLStore) deleteTask(q dbtx, id string) error {
+	result, err := q.Exec(s.q(`UPDATE scheduler SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`),
+		nowTimestamp(),
+		id,
 	)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkAffected(result)
 }
 
-func DeleteTaskByID(id string) error {
+func (s *SQLStore) Reschedule(id string, date string) error {
+	return s.rescheduleTask(s.db, id, date)
+}
+
+func (s *SQLStore) rescheduleTask(q dbtx, id string, date string) error {
+	result, err := q.Exec(s.q(`UPDATE scheduler SET date = ? WHERE id = ? AND deleted_at IS NULL`), date, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+func (s *SQLStore) Complete(id string, now time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	task, err := s.getTask(tx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.completeTask(tx, task, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// completeTask выполняет задачу task в рамках транзакции q
+func (s *SQLStore) completeTask(q dbtx, task models.FullTask, now time.Time) error {
+	if task.Repeat == "" {
+		// Выполненная разовая задача, как и удалённая, попадает в корзину
+		_, err := q.Exec(s.q(`UPDATE scheduler SET deleted_at = ? WHERE id = ?`), nowTimestamp(), task.ID)
+		return err
+	}
 
-	_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+	nextDate, err := utils.NextDate(now, task.Date, task.Repeat)
+	if err != nil {
+		return err
+	}
 
+	projectID, err := projectIDValue(task.ProjectID)
+	if err != nil {
+		return err
+	}
+	position, err := s.nextPosition(q, projectID, models.TaskStatusTodo)
 	if err != nil {
 		return err
 	}
 
+	// Следующее повторение начинается заново: в колонке todo и с невыполненным чек-листом
+	if _, err = q.Exec(s.q(`UPDATE scheduler SET date = ?, status = ?, position = ? WHERE id = ?`),
+		nextDate, models.TaskStatusTodo, position, task.ID); err != nil {
+		return err
+	}
+
+	// Повторяющаяся задача не попадает в корзину, поэтому ждущие её задачи освобождаются явно:
+	// выполненное повторение снимает блокировку. Снимок задачи до выполнения хранит их для отмены
+	if _, err = q.Exec(s.q(`DELETE FROM task_dependencies WHERE blocker_id = ?`), task.ID); err != nil {
+		return err
+	}
+
+	return s.resetChecklist(q, task.ID)
+}
+
+// optionalInt возвращает значение для необязательного числового столбца (priority, estimate_minutes): 0, если оно не задано
+func optionalInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// checkAffected возвращает ErrTaskNotFound, если запрос не затронул ни одной строки
+func checkAffected(result sql.Result) error {
+	return expectAffected(result, ErrTaskNotFound)
+}
+
+// expectAffected возвращает errNotFound, если запрос не затронул ни одной строки
+func expectAffected(result sql.Result, errNotFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errNotFound
+	}
 	return nil
+}
 
+// nowTimestamp возвращает текущее время в формате, в котором в базе хранятся отметки времени
+func nowTimestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
 }
@@ -0,0 +1,171 @@
+package dbutils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrDependencyCycle    = errors.New("зависимость образует цикл")
+	ErrDependencyNotFound = errors.New("зависимость не найдена")
+)
+
+// DependencyStore описывает хранилище зависимостей между задачами.
+// Блокирующая задача считается открытой, пока она не в корзине, то есть не выполнена и не удалена,
+// и не перенесена на доске в колонку done.
+// Повторяющаяся задача при выполнении остаётся на месте, поэтому её зависимости удаляются при выполнении
+// очередного повторения. Снимок задачи хранит ждущие её задачи (Blocks), по нему отмена выполнения
+// возвращает удалённые зависимости.
+type DependencyStore interface {
+	// AddDependency отмечает, что задача taskID заблокирована задачей blockerID.
+	// Возвращает ErrDependencyCycle, если blockerID сама прямо или косвенно ждёт taskID.
+	AddDependency(taskID, blockerID string) error
+	// RemoveDependency снимает блокировку задачи taskID задачей blockerID
+	RemoveDependency(taskID, blockerID string) error
+}
+
+func (s *SQLStore) AddDependency(taskID, blockerID string) error {
+	if taskID == blockerID {
+		return ErrDependencyCycle
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range []string{taskID, blockerID} {
+		if _, err = s.getTask(tx, id); err != nil {
+			return err
+		}
+	}
+
+	cycle, err := s.dependencyCycle(tx, taskID, blockerID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	if _, err = tx.Exec(s.q(`INSERT INTO task_dependencies (task_id, blocker_id) VALUES (?, ?) ON CONFLICT DO NOTHING`),
+		taskID, blockerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dependencyCycle проверяет, образует ли цикл блокировка задачи taskID задачей blockerID:
+// цикл появится, если taskID уже входит в цепочку задач, блокирующих blockerID
+func (s *SQLStore) dependencyCycle(q dbtx, taskID, blockerID string) (bool, error) {
+	var cycles int
+	err := q.QueryRow(s.q(`
+		WITH RECURSIVE chain(id) AS (
+			SELECT blocker_id FROM task_dependencies WHERE task_id = ?
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d JOIN chain c ON d.task_id = c.id
+		)
+		SELECT COUNT(*) FROM chain WHERE id = ?`),
+		blockerID,
+		taskID,
+	).Scan(&cycles)
+	return cycles > 0, err
+}
+
+func (s *SQLStore) RemoveDependency(taskID, blockerID string) error {
+	result, err := s.db.Exec(s.q(`DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?`), taskID, blockerID)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrDependencyNotFound)
+}
+
+// loadBlockers заполняет у переданных задач список открытых блокирующих задач одним запросом
+func (s *SQLStore) loadBlockers(q dbtx, tasks []models.FullTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks)+1)
+	args = append(args, models.TaskStatusDone)
+	for i, task := range tasks {
+		index[task.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+	}
+
+	rows, err := q.Query(s.q(`
+		SELECT d.task_id, d.blocker_id
+		FROM task_dependencies d
+		JOIN scheduler b ON b.id = d.blocker_id AND b.deleted_at IS NULL AND b.status <> ?
+		WHERE d.task_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY d.blocker_id`),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID, blockerID int64
+		if err := rows.Scan(&taskID, &blockerID); err != nil {
+			return err
+		}
+		if i, ok := index[strconv.FormatInt(taskID, 10)]; ok {
+			tasks[i].BlockedBy = append(tasks[i].BlockedBy, strconv.FormatInt(blockerID, 10))
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadDependents возвращает задачи, которые ждут задачу blockerID, в том числе находящиеся в корзине
+func (s *SQLStore) loadDependents(q dbtx, blockerID string) ([]string, error) {
+	rows, err := q.Query(s.q(`SELECT task_id FROM task_dependencies WHERE blocker_id = ? ORDER BY task_id`), blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependents []string
+	for rows.Next() {
+		var taskID int64
+		if err := rows.Scan(&taskID); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, strconv.FormatInt(taskID, 10))
+	}
+
+	return dependents, rows.Err()
+}
+
+// restoreDependents возвращает зависимости задач, ждавших задачу blockerID, из снимка задачи.
+// Задачи, удалённые с тех пор окончательно, и зависимости, которые теперь образовали бы цикл, пропускаются.
+func (s *SQLStore) restoreDependents(q dbtx, blockerID string, dependents []string) error {
+	for _, taskID := range dependents {
+		cycle, err := s.dependencyCycle(q, taskID, blockerID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			continue
+		}
+
+		if _, err = q.Exec(s.q(`INSERT INTO task_dependencies (task_id, blocker_id)
+			SELECT id, ? FROM scheduler WHERE id = ?
+			ON CONFLICT DO NOTHING`), blockerID, taskID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package dbutils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect определяет вид SQL-сервера, с которым работает хранилище
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// rebind заменяет плейсхолдеры '?' на принятые в диалекте.
+// Запросы хранилища пишутся с '?', для PostgreSQL они превращаются в $1, $2 и т.д.
+func (d Dialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	b.Grow(len(query) + 8)
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package dbutils
+
+import (
+	"strconv"
+	"strings"
+
+	"webtasksplannerexample/internal/models"
+)
+
+// Задачи выгружаются порциями, чтобы не держать открытым курсор, пока обрабатывается каждая задача
+const exportBatchSize = 500
+
+// ExportStore описывает выгрузку всех задач
+type ExportStore interface {
+	// ExportTasks передаёт в fn все задачи, включая находящиеся в корзине, по возрастанию идентификатора,
+	// с метками и историей изменений. Ошибка fn прекращает выгрузку и возвращается вызывающему.
+	ExportTasks(fn func(task models.ExportTask) error) error
+}
+
+func (s *SQLStore) ExportTasks(fn func(task models.ExportTask) error) error {
+	var lastID int64
+
+	for {
+		rows, err := s.db.Query(s.q(`SELECT `+taskColumns+` FROM scheduler WHERE id > ? ORDER BY id ASC LIMIT ?`),
+			lastID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		tasks, err := scanTasks(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		if err = s.loadTags(s.db, tasks); err != nil {
+			return err
+		}
+		history, err := s.loadHistory(tasks)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if err = fn(models.ExportTask{FullTask: task, History: history[task.ID]}); err != nil {
+				return err
+			}
+		}
+
+		if lastID, err = strconv.ParseInt(tasks[len(tasks)-1].ID, 10, 64); err != nil {
+			return err
+		}
+		if len(tasks) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// loadHistory возвращает записи журнала изменений переданных задач, сгруппированные по идентификатору задачи
+func (s *SQLStore) loadHistory(tasks []models.FullTask) (map[string][]models.ExportHistoryEntry, error) {
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks))
+	for _, task := range tasks {
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+	}
+
+	rows, err := s.db.Query(s.q(`
+		SELECT task_id, action, actor, created_at FROM audit_log
+		WHERE task_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY id ASC`),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]models.ExportHistoryEntry, len(tasks))
+	for rows.Next() {
+		var (
+			taskID int64
+			entry  models.ExportHistoryEntry
+		)
+		if err := rows.Scan(&taskID, &entry.Action, &entry.Actor, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		id := strconv.FormatInt(taskID, 10)
+		history[id] = append(history[id], entry)
+	}
+
+	return history, rows.Err()
+}
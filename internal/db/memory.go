@@ -0,0 +1,47 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MemoryDSN возвращает строку подключения к базе SQLite в оперативной памяти с именем name.
+// Все подключения процесса с одинаковым именем работают с одной и той же базой.
+func MemoryDSN(name string) string {
+	return "file:" + name + "?mode=memory&cache=shared&" + sqliteForeignKeys
+}
+
+// IsMemoryDSN сообщает, запрошено ли хранилище в памяти (TODO_DB_DSN=memory://<имя>)
+func IsMemoryDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "memory://")
+}
+
+// NewMemoryStore создаёт хранилище в оперативной памяти для тестов и демонстрации.
+// Используется та же реализация, что и для файла SQLite, поэтому поведение хранилищ совпадает,
+// но данные живут только до вызова Close. Миграции применяются сразу.
+func NewMemoryStore(name string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", MemoryDSN(name))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных в памяти: %w", err)
+	}
+
+	// База в памяти существует, пока открыто хотя бы одно подключение к ней,
+	// поэтому одно подключение удерживается за хранилищем до его закрытия
+	keepAlive, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось открыть базу данных в памяти: %w", err)
+	}
+
+	store := NewSQLiteStore(db)
+	store.keepAlive = keepAlive
+
+	if _, err = store.Migrate(); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
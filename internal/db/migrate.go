@@ -0,0 +1,192 @@
+package dbutils
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Файлы миграций лежат в каталоге своего диалекта (migrations/sqlite, migrations/postgres)
+// и именуются как <версия>_<описание>.sql, например 0001_create_scheduler.sql.
+// Миграции применяются только вверх, в порядке возрастания версии.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationsFS embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("некорректная версия в имени файла миграции: %s", fileName)
+		}
+		if prev, exists := seen[version]; exists {
+			return nil, fmt.Errorf("версия миграции %d повторяется: %s и %s", version, prev, fileName)
+		}
+		seen[version] = fileName
+
+		data, err := migrationsFS.ReadFile(path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER PRIMARY KEY,
+        name VARCHAR(128) NOT NULL,
+        applied_at VARCHAR(32) NOT NULL
+    )`)
+	return err
+}
+
+func appliedMigrations(db *sql.DB) (map[int]string, error) {
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]string)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt string
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Migrate применяет все ещё не применённые миграции, каждую в отдельной транзакции.
+// Возвращает список применённых за этот вызов миграций.
+func Migrate(db *sql.DB, dialect Dialect) ([]Migration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("не удалось создать таблицу 'schema_migrations': %w", err)
+	}
+
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить миграции: %w", err)
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать список применённых миграций: %w", err)
+	}
+
+	done := []Migration{}
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := applyMigration(db, dialect, m); err != nil {
+			return done, fmt.Errorf("ошибка применения миграции %04d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func applyMigration(db *sql.DB, dialect Dialect, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(m.SQL); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(dialect.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.Version,
+		m.Name,
+		nowTimestamp(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetMigrationStatus возвращает состояние всех известных миграций в порядке возрастания версии
+func GetMigrationStatus(db *sql.DB, dialect Dialect) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("не удалось создать таблицу 'schema_migrations': %w", err)
+	}
+
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить миграции: %w", err)
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать список применённых миграций: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,11 @@
+-- Таблица задач планировщика и индекс по дате.
+-- Длина title не ограничивается: SQLite не проверяет VARCHAR(64), и поведение хранилищ должно совпадать.
+CREATE TABLE IF NOT EXISTS scheduler (
+    id BIGSERIAL PRIMARY KEY,
+    date VARCHAR(8) NOT NULL,
+    title TEXT NOT NULL,
+    comment TEXT CHECK(length(comment) <= 512),
+    repeat  VARCHAR(128)
+);
+
+CREATE INDEX IF NOT EXISTS idx_date ON scheduler(date);
@@ -0,0 +1,4 @@
+-- Отметка о перемещении задачи в корзину, NULL у действующих задач
+ALTER TABLE scheduler ADD COLUMN deleted_at VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS idx_deleted_at ON scheduler(deleted_at);
@@ -0,0 +1,4 @@
+-- Отметка об отмене действия через POST /api/undo, NULL у неотменённых записей
+ALTER TABLE audit_log ADD COLUMN undone_at VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor, created_at);
@@ -0,0 +1,12 @@
+-- Проекты (списки) для группировки задач. Задача без проекта имеет project_id = NULL.
+CREATE TABLE IF NOT EXISTS projects (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at VARCHAR(32) NOT NULL
+);
+
+ALTER TABLE scheduler ADD COLUMN project_id BIGINT REFERENCES projects(id);
+
+CREATE INDEX IF NOT EXISTS idx_project_id ON scheduler(project_id);
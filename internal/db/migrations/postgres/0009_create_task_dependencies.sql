@@ -0,0 +1,8 @@
+-- Зависимости задач: задача task_id заблокирована задачей blocker_id, пока та не выполнена
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id BIGINT NOT NULL REFERENCES scheduler(id) ON DELETE CASCADE,
+    blocker_id BIGINT NOT NULL REFERENCES scheduler(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker_id ON task_dependencies(blocker_id);
@@ -0,0 +1,14 @@
+-- Статус задачи на доске (todo, in_progress, waiting, done) и её позиция внутри колонки статуса
+ALTER TABLE scheduler ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'todo';
+ALTER TABLE scheduler ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_status ON scheduler(status);
+
+-- Настроенные колонки доски проекта. Если у проекта их нет, доска показывает все статусы.
+CREATE TABLE IF NOT EXISTS project_columns (
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL,
+    title VARCHAR(64) NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (project_id, status)
+);
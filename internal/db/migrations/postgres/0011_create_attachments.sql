@@ -0,0 +1,13 @@
+-- Сведения о вложениях задач, содержимое хранится файлами в каталоге вложений под именем storage_name.
+-- Внешнего ключа на scheduler нет: после окончательного удаления задачи записи нужны, чтобы удалить файлы.
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_name VARCHAR(64) NOT NULL,
+    created_at VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_task_id ON attachments(task_id);
@@ -0,0 +1,12 @@
+-- Шаблоны задач. Метки и пункты чек-листа хранятся JSON-массивами строк,
+-- date_offset - через сколько дней после даты создания ставится задача.
+CREATE TABLE IF NOT EXISTS task_templates (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(256) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    repeat VARCHAR(128) NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '[]',
+    checklist TEXT NOT NULL DEFAULT '[]',
+    date_offset INTEGER NOT NULL DEFAULT 0,
+    created_at VARCHAR(32) NOT NULL
+);
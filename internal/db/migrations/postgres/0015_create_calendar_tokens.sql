@@ -0,0 +1,7 @@
+-- Секретные токены календарных подписок: по одному на пользователя (X-User).
+-- Хранится только SHA-256 токена, сам токен показывается один раз при выпуске.
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    actor VARCHAR(64) PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at VARCHAR(32) NOT NULL
+);
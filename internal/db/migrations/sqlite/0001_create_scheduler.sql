@@ -0,0 +1,11 @@
+-- Таблица задач планировщика и индекс по дате.
+-- IF NOT EXISTS оставлен для баз, созданных до появления миграций.
+CREATE TABLE IF NOT EXISTS scheduler (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date VARCHAR(8) NOT NULL,
+    title VARCHAR(64) NOT NULL,
+    comment TEXT CHECK(length(comment) <= 512),
+    repeat  VARCHAR(128)
+);
+
+CREATE INDEX IF NOT EXISTS idx_date ON scheduler(date);
@@ -0,0 +1,15 @@
+-- Журнал изменений задач. Записи не ссылаются на scheduler внешним ключом,
+-- чтобы история сохранялась и после окончательного удаления задачи.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    actor VARCHAR(64) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    before_data TEXT,
+    after_data TEXT,
+    request_id VARCHAR(64),
+    created_at VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_task_id ON audit_log(task_id);
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_log(created_at);
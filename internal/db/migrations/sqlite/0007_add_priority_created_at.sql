@@ -0,0 +1,4 @@
+-- Приоритет задачи от 1 (наивысший) до 4, 0 - приоритет не задан.
+-- Время создания нужно для сортировки, у задач, созданных до миграции, оно пустое.
+ALTER TABLE scheduler ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scheduler ADD COLUMN created_at VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Пункты чек-листа задачи, упорядоченные по position
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES scheduler(id) ON DELETE CASCADE,
+    title VARCHAR(256) NOT NULL,
+    done INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_task_id ON checklist_items(task_id);
@@ -0,0 +1,11 @@
+-- Обсуждение задачи: комментарии пользователей. Поле comment в scheduler остаётся описанием задачи.
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES scheduler(id) ON DELETE CASCADE,
+    author VARCHAR(64) NOT NULL,
+    text TEXT NOT NULL,
+    created_at VARCHAR(32) NOT NULL,
+    updated_at VARCHAR(32)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id);
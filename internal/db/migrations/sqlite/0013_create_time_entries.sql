@@ -0,0 +1,15 @@
+-- Оценка трудоёмкости задачи в минутах, 0 - не задана
+ALTER TABLE scheduler ADD COLUMN estimate_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- Учёт времени по задачам. У запущенного таймера ended_at пустой, а duration_seconds равен 0.
+CREATE TABLE IF NOT EXISTS time_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES scheduler(id) ON DELETE CASCADE,
+    actor VARCHAR(64) NOT NULL,
+    started_at VARCHAR(32) NOT NULL,
+    ended_at VARCHAR(32),
+    duration_seconds INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries(started_at);
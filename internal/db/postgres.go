@@ -0,0 +1,29 @@
+package dbutils
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// IsPostgresDSN сообщает, указывает ли строка подключения на сервер PostgreSQL
+func IsPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// OpenPostgres открывает подключение к PostgreSQL и проверяет его доступность
+func OpenPostgres(dsn string) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных: %w", err)
+	}
+
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("не удалось подключиться к PostgreSQL: %w", err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,174 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrProjectNotFound = errors.New("проект не найден")
+	ErrProjectArchived = errors.New("проект находится в архиве")
+)
+
+// ProjectStore описывает хранилище проектов, по которым группируются задачи
+type ProjectStore interface {
+	// AddProject сохраняет новый проект и возвращает его идентификатор
+	AddProject(project models.Project) (int64, error)
+	// ListProjects возвращает проекты, архивные - только при includeArchived
+	ListProjects(includeArchived bool) ([]models.Project, error)
+	// GetProject возвращает проект по идентификатору или ErrProjectNotFound
+	GetProject(id string) (models.Project, error)
+	// UpdateProject перезаписывает поля проекта
+	UpdateProject(project models.Project) error
+	// DeleteProject удаляет проект, его задачи (включая находящиеся в корзине) остаются без проекта
+	DeleteProject(id string) error
+}
+
+func (s *SQLStore) AddProject(project models.Project) (int64, error) {
+	var id int64
+
+	err := s.db.QueryRow(s.q(
+		"INSERT INTO projects (name, color, archived, created_at) VALUES (?, ?, ?, ?) RETURNING id"),
+		project.Name,
+		project.Color,
+		project.Archived,
+		nowTimestamp(),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *SQLStore) ListProjects(includeArchived bool) ([]models.Project, error) {
+	query := `SELECT id, name, color, archived FROM projects`
+	args := []any{}
+	if !includeArchived {
+		query += ` WHERE archived = ?`
+		args = append(args, false)
+	}
+
+	rows, err := s.db.Query(s.q(query+` ORDER BY name ASC, id ASC`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func scanProject(row rowScanner) (models.Project, error) {
+	var (
+		project models.Project
+		id      int64
+	)
+	if err := row.Scan(&id, &project.Name, &project.Color, &project.Archived); err != nil {
+		return models.Project{}, err
+	}
+	project.ID = strconv.FormatInt(id, 10)
+	return project, nil
+}
+
+func (s *SQLStore) GetProject(id string) (models.Project, error) {
+	project, err := scanProject(s.db.QueryRow(s.q(`SELECT id, name, color, archived FROM projects WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return models.Project{}, ErrProjectNotFound
+	}
+	return project, err
+}
+
+func (s *SQLStore) UpdateProject(project models.Project) error {
+	result, err := s.db.Exec(s.q(`UPDATE projects SET name = ?, color = ?, archived = ? WHERE id = ?`),
+		project.Name,
+		project.Color,
+		project.Archived,
+		project.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrProjectNotFound)
+}
+
+func (s *SQLStore) DeleteProject(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Внешний ключ scheduler.project_id задан без ON DELETE, поэтому задачи отвязываются от проекта явно.
+	// Колонки доски проекта удаляются каскадно.
+	if _, err = tx.Exec(s.q(`UPDATE scheduler SET project_id = NULL WHERE project_id = ?`), id); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(s.q(`DELETE FROM projects WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(result, ErrProjectNotFound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// projectIDValue возвращает значение для столбца project_id: nil, если проект не указан
+func projectIDValue(projectID *string) (any, error) {
+	if projectID == nil || *projectID == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(*projectID, 10, 64)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return id, nil
+}
+
+// sameProject сравнивает проекты задач, nil и пустая строка означают отсутствие проекта
+func sameProject(a, b *string) bool {
+	if a == nil || *a == "" {
+		return b == nil || *b == ""
+	}
+	return b != nil && *a == *b
+}
+
+// checkProjectAssignable проверяет, что задачу можно поместить в проект projectID.
+// В архивный проект задачу переносить нельзя, но задача, уже находящаяся в нём (currentProjectID), может в нём остаться.
+func (s *SQLStore) checkProjectAssignable(q dbtx, projectID any, currentProjectID *string) error {
+	if projectID == nil {
+		return nil
+	}
+
+	var archived bool
+	err := q.QueryRow(s.q(`SELECT archived FROM projects WHERE id = ?`), projectID).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if archived && (currentProjectID == nil || *currentProjectID != strconv.FormatInt(projectID.(int64), 10)) {
+		return ErrProjectArchived
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package dbutils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrTaskNotFound = errors.New("задача не найдена")
+)
+
+// TaskStore описывает хранилище задач планировщика
+type TaskStore interface {
+	// Add сохраняет новую задачу и возвращает её идентификатор
+	Add(task models.Task) (int64, error)
+	// List возвращает ближайшие задачи, отобранные по фильтру
+	List(filter models.TaskFilter) ([]models.FullTask, error)
+	// Get возвращает задачу по идентификатору или ErrTaskNotFound
+	Get(id string) (models.FullTask, error)
+	// Update перезаписывает поля задачи
+	Update(task models.FullTask) error
+	// Delete удаляет задачу (перемещает в корзину)
+	Delete(id string) error
+	// Complete отмечает задачу выполненной: разовая задача перемещается в корзину,
+	// у повторяющейся дата переносится на следующую после now
+	Complete(id string, now time.Time) error
+	// Reschedule переносит задачу на дату date, не меняя правило повторения
+	Reschedule(id string, date string) error
+}
+
+// Store объединяет все возможности хранилища, которые использует веб-сервер
+type Store interface {
+	TaskStore
+	TrashStore
+	AuditStore
+	UndoStore
+	TagStore
+	ProjectStore
+	ChecklistStore
+	DependencyStore
+	BoardStore
+	AttachmentStore
+	CommentStore
+	TimeStore
+	TemplateStore
+	BulkStore
+	ExportStore
+	CalendarStore
+}
+
+// OpenStore открывает хранилище согласно настройкам сервиса: при заданной строке подключения
+// TODO_DB_DSN используется PostgreSQL (postgres://...) или база в памяти (memory://<имя>),
+// иначе файл SQLite по пути DbFilePath.
+// Миграции не применяются, для этого нужно вызвать Migrate.
+func OpenStore(conf models.ServiceConfig) (*SQLStore, error) {
+	if conf.DbDSN == "" {
+		db, err := OpenDB(conf.DbFilePath)
+		if err != nil {
+			return nil, err
+		}
+		return NewSQLiteStore(db), nil
+	}
+
+	if IsMemoryDSN(conf.DbDSN) {
+		name := strings.TrimPrefix(conf.DbDSN, "memory://")
+		if name == "" {
+			name = "scheduler"
+		}
+		return NewMemoryStore(name)
+	}
+
+	if !IsPostgresDSN(conf.DbDSN) {
+		return nil, fmt.Errorf("неподдерживаемая строка подключения TODO_DB_DSN, ожидается postgres://... или memory://...")
+	}
+
+	db, err := OpenPostgres(conf.DbDSN)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgresStore(db), nil
+}
@@ -0,0 +1,109 @@
+package dbutils
+
+import (
+	"strconv"
+	"strings"
+
+	"webtasksplannerexample/internal/models"
+)
+
+// TagStore описывает справочник меток задач
+type TagStore interface {
+	// ListTags возвращает метки с количеством действующих задач, отмеченных ими
+	ListTags() ([]models.TagCount, error)
+}
+
+func (s *SQLStore) ListTags() ([]models.TagCount, error) {
+	rows, err := s.db.Query(s.q(`
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN task_tags tt ON tt.tag_id = t.id
+		JOIN scheduler s ON s.id = tt.task_id AND s.deleted_at IS NULL
+		GROUP BY t.name
+		ORDER BY t.name`))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagCount{}
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// setTaskTags заменяет метки задачи на tags, недостающие метки добавляются в справочник
+func (s *SQLStore) setTaskTags(q dbtx, taskID int64, tags []string) error {
+	if _, err := q.Exec(s.q(`DELETE FROM task_tags WHERE task_id = ?`), taskID); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		var tagID int64
+
+		if _, err := q.Exec(s.q(`INSERT INTO tags (name) VALUES (?) ON CONFLICT (name) DO NOTHING`), tag); err != nil {
+			return err
+		}
+		if err := q.QueryRow(s.q(`SELECT id FROM tags WHERE name = ?`), tag).Scan(&tagID); err != nil {
+			return err
+		}
+		if _, err := q.Exec(s.q(`INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING`),
+			taskID, tagID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags заполняет метки у переданных задач одним запросом
+func (s *SQLStore) loadTags(q dbtx, tasks []models.FullTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]any, 0, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+	}
+
+	rows, err := q.Query(s.q(`
+		SELECT tt.task_id, t.name
+		FROM task_tags tt JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY t.name`),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			taskID int64
+			name   string
+		)
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return err
+		}
+		if i, ok := index[strconv.FormatInt(taskID, 10)]; ok {
+			tasks[i].Tags = append(tasks[i].Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,180 @@
+package dbutils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("шаблон не найден")
+)
+
+// TemplateStore описывает хранилище шаблонов задач
+type TemplateStore interface {
+	// AddTemplate сохраняет новый шаблон и возвращает его идентификатор
+	AddTemplate(template models.TaskTemplate) (int64, error)
+	// ListTemplates возвращает шаблоны по заголовку
+	ListTemplates() ([]models.TaskTemplate, error)
+	// GetTemplate возвращает шаблон по идентификатору или ErrTemplateNotFound
+	GetTemplate(id string) (models.TaskTemplate, error)
+	// UpdateTemplate перезаписывает поля шаблона
+	UpdateTemplate(template models.TaskTemplate) error
+	// DeleteTemplate удаляет шаблон, созданные по нему задачи не меняются
+	DeleteTemplate(id string) error
+	// InstantiateTemplate создаёт count копий задачи task, каждую с пунктами чек-листа checklist, и возвращает
+	// их идентификаторы. Задачи создаются в одной транзакции: при ошибке не сохраняется ни одна.
+	InstantiateTemplate(task models.Task, checklist []string, count int) ([]int64, error)
+}
+
+const templateColumns = "id, title, comment, repeat, tags, checklist, date_offset"
+
+func scanTemplate(row rowScanner) (models.TaskTemplate, error) {
+	var (
+		template  models.TaskTemplate
+		id        int64
+		tags      string
+		checklist string
+	)
+	if err := row.Scan(&id, &template.Title, &template.Comment, &template.Repeat, &tags, &checklist, &template.DateOffset); err != nil {
+		return models.TaskTemplate{}, err
+	}
+	template.ID = strconv.FormatInt(id, 10)
+	if err := json.Unmarshal([]byte(tags), &template.Tags); err != nil {
+		return models.TaskTemplate{}, err
+	}
+	if err := json.Unmarshal([]byte(checklist), &template.Checklist); err != nil {
+		return models.TaskTemplate{}, err
+	}
+	return template, nil
+}
+
+// marshalTemplateList сериализует список строк шаблона, отсутствующий список хранится как пустой
+func marshalTemplateList(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
+
+func (s *SQLStore) AddTemplate(template models.TaskTemplate) (int64, error) {
+	tags, err := marshalTemplateList(template.Tags)
+	if err != nil {
+		return 0, err
+	}
+	checklist, err := marshalTemplateList(template.Checklist)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = s.db.QueryRow(s.q(`INSERT INTO task_templates (title, comment, repeat, tags, checklist, date_offset, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		template.Title,
+		template.Comment,
+		template.Repeat,
+		tags,
+		checklist,
+		template.DateOffset,
+		nowTimestamp(),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *SQLStore) ListTemplates() ([]models.TaskTemplate, error) {
+	rows, err := s.db.Query(`SELECT ` + templateColumns + ` FROM task_templates ORDER BY title ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.TaskTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (s *SQLStore) GetTemplate(id string) (models.TaskTemplate, error) {
+	template, err := scanTemplate(s.db.QueryRow(s.q(`SELECT `+templateColumns+` FROM task_templates WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return models.TaskTemplate{}, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (s *SQLStore) UpdateTemplate(template models.TaskTemplate) error {
+	tags, err := marshalTemplateList(template.Tags)
+	if err != nil {
+		return err
+	}
+	checklist, err := marshalTemplateList(template.Checklist)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(s.q(`UPDATE task_templates
+		SET title = ?, comment = ?, repeat = ?, tags = ?, checklist = ?, date_offset = ? WHERE id = ?`),
+		template.Title,
+		template.Comment,
+		template.Repeat,
+		tags,
+		checklist,
+		template.DateOffset,
+		template.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrTemplateNotFound)
+}
+
+func (s *SQLStore) DeleteTemplate(id string) error {
+	result, err := s.db.Exec(s.q(`DELETE FROM task_templates WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result, ErrTemplateNotFound)
+}
+
+func (s *SQLStore) InstantiateTemplate(task models.Task, checklist []string, count int) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, count)
+	for range count {
+		id, err := s.addTask(tx, task)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range checklist {
+			if _, err = s.addChecklistItem(tx, strconv.FormatInt(id, 10), item); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, tx.Commit()
+}
@@ -0,0 +1,286 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"webtasksplannerexample/internal/models"
+	"webtasksplannerexample/internal/utils"
+)
+
+var (
+	ErrTimerRunning    = errors.New("у пользователя уже запущен таймер")
+	ErrTimerNotRunning = errors.New("таймер по задаче не запущен")
+)
+
+// TimeStore описывает учёт времени по задачам. У каждого пользователя может быть запущен только один таймер.
+type TimeStore interface {
+	// StartTimer запускает таймер пользователя actor по задаче taskID и возвращает идентификатор записи
+	StartTimer(taskID string, actor string, now time.Time) (int64, error)
+	// StopTimer останавливает запущенный таймер пользователя actor по задаче taskID и возвращает запись
+	StopTimer(taskID string, actor string, now time.Time) (models.TimeEntry, error)
+	// AddTimeEntry сохраняет завершённую запись о затраченном времени, указанном вручную
+	AddTimeEntry(entry models.TimeEntry) (int64, error)
+	// ListTimeEntries возвращает записи учёта времени по задаче в порядке начала
+	ListTimeEntries(taskID string) ([]models.TimeEntry, error)
+	// TimeReport возвращает учтённое и оценочное время по проектам и неделям, проекты - по возрастанию
+	// идентификатора, задачи без проекта первыми. Оценка проекта суммируется только по задачам, по которым
+	// за период учтено время, поэтому показывает, сколько было запланировано на фактически сделанную работу.
+	// Запущенные таймеры в отчёт не входят.
+	TimeReport(filter models.TimeReportFilter) (models.TimeReport, error)
+}
+
+const timeEntryColumns = "id, task_id, actor, started_at, ended_at, duration_seconds"
+
+func scanTimeEntry(row rowScanner) (models.TimeEntry, error) {
+	var (
+		entry   models.TimeEntry
+		id      int64
+		taskID  int64
+		endedAt sql.NullString
+	)
+	if err := row.Scan(&id, &taskID, &entry.Actor, &entry.StartedAt, &endedAt, &entry.DurationSeconds); err != nil {
+		return models.TimeEntry{}, err
+	}
+	entry.ID = strconv.FormatInt(id, 10)
+	entry.TaskID = strconv.FormatInt(taskID, 10)
+	entry.EndedAt = endedAt.String
+	return entry, nil
+}
+
+func (s *SQLStore) StartTimer(taskID string, actor string, now time.Time) (int64, error) {
+	var (
+		id      int64
+		running int
+	)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, taskID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.QueryRow(s.q(`SELECT COUNT(*) FROM time_entries WHERE actor = ? AND ended_at IS NULL`),
+		actor).Scan(&running); err != nil {
+		return 0, err
+	}
+	if running > 0 {
+		return 0, ErrTimerRunning
+	}
+
+	err = tx.QueryRow(s.q(`INSERT INTO time_entries (task_id, actor, started_at) VALUES (?, ?, ?) RETURNING id`),
+		taskID,
+		actor,
+		now.UTC().Format(time.RFC3339),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *SQLStore) StopTimer(taskID string, actor string, now time.Time) (models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	defer tx.Rollback()
+
+	entry, err := scanTimeEntry(tx.QueryRow(s.q(`SELECT `+timeEntryColumns+` FROM time_entries
+		WHERE task_id = ? AND actor = ? AND ended_at IS NULL`), taskID, actor))
+	if err == sql.ErrNoRows {
+		return models.TimeEntry{}, ErrTimerNotRunning
+	}
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, entry.StartedAt)
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	entry.EndedAt = now.UTC().Format(time.RFC3339)
+	entry.DurationSeconds = int64(max(now.Sub(startedAt), 0) / time.Second)
+
+	if _, err = tx.Exec(s.q(`UPDATE time_entries SET ended_at = ?, duration_seconds = ? WHERE id = ?`),
+		entry.EndedAt, entry.DurationSeconds, entry.ID); err != nil {
+		return models.TimeEntry{}, err
+	}
+
+	return entry, tx.Commit()
+}
+
+func (s *SQLStore) AddTimeEntry(entry models.TimeEntry) (int64, error) {
+	var id int64
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err = s.getTask(tx, entry.TaskID); err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(s.q(`INSERT INTO time_entries (task_id, actor, started_at, ended_at, duration_seconds)
+		VALUES (?, ?, ?, ?, ?) RETURNING id`),
+		entry.TaskID,
+		entry.Actor,
+		entry.StartedAt,
+		entry.EndedAt,
+		entry.DurationSeconds,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *SQLStore) ListTimeEntries(taskID string) ([]models.TimeEntry, error) {
+	if _, err := s.getTask(s.db, taskID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(s.q(`SELECT `+timeEntryColumns+` FROM time_entries
+		WHERE task_id = ? ORDER BY started_at ASC, id ASC`), taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *SQLStore) TimeReport(filter models.TimeReportFilter) (models.TimeReport, error) {
+	// Учитывается время и по задачам в корзине: оно уже затрачено
+	query := `
+		SELECT e.task_id, e.started_at, e.duration_seconds, s.estimate_minutes, s.project_id, COALESCE(p.name, '')
+		FROM time_entries e
+		JOIN scheduler s ON s.id = e.task_id
+		LEFT JOIN projects p ON p.id = s.project_id
+		WHERE e.ended_at IS NOT NULL`
+	args := []any{}
+	if filter.ProjectID != "" {
+		query += ` AND s.project_id = ?`
+		args = append(args, filter.ProjectID)
+	}
+	if filter.From != "" {
+		query += ` AND e.started_at >= ?`
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += ` AND e.started_at < ?`
+		args = append(args, filter.To)
+	}
+
+	rows, err := s.db.Query(s.q(query), args...)
+	if err != nil {
+		return models.TimeReport{}, err
+	}
+	defer rows.Close()
+
+	type weekKey struct{ week, projectID string }
+
+	var (
+		projects       = map[string]*models.ProjectTimeSummary{}
+		projectSeconds = map[string]int64{}
+		weekSeconds    = map[weekKey]int64{}
+		estimated      = map[int64]bool{}
+	)
+	for rows.Next() {
+		var (
+			taskID    int64
+			startedAt string
+			seconds   int64
+			estimate  int64
+			projectID sql.NullInt64
+			name      string
+		)
+		if err := rows.Scan(&taskID, &startedAt, &seconds, &estimate, &projectID, &name); err != nil {
+			return models.TimeReport{}, err
+		}
+
+		project := ""
+		if projectID.Valid {
+			project = strconv.FormatInt(projectID.Int64, 10)
+		}
+		summary, ok := projects[project]
+		if !ok {
+			summary = &models.ProjectTimeSummary{ProjectID: project, ProjectName: name}
+			projects[project] = summary
+		}
+		projectSeconds[project] += seconds
+		if !estimated[taskID] {
+			estimated[taskID] = true
+			summary.EstimateMinutes += estimate
+		}
+
+		started, err := time.Parse(time.RFC3339, startedAt)
+		if err != nil {
+			return models.TimeReport{}, err
+		}
+		weekSeconds[weekKey{utils.WeekStart(started.UTC()).Format(utils.DateFormat), project}] += seconds
+	}
+	if err = rows.Err(); err != nil {
+		return models.TimeReport{}, err
+	}
+
+	report := models.TimeReport{Projects: []models.ProjectTimeSummary{}, Weeks: []models.WeekTimeSummary{}}
+	for project, summary := range projects {
+		summary.TrackedMinutes = secondsToMinutes(projectSeconds[project])
+		report.Projects = append(report.Projects, *summary)
+	}
+	for key, seconds := range weekSeconds {
+		report.Weeks = append(report.Weeks, models.WeekTimeSummary{
+			WeekStart:      key.week,
+			ProjectID:      key.projectID,
+			TrackedMinutes: secondsToMinutes(seconds),
+		})
+	}
+
+	sort.Slice(report.Projects, func(i, j int) bool {
+		return projectIDLess(report.Projects[i].ProjectID, report.Projects[j].ProjectID)
+	})
+	sort.Slice(report.Weeks, func(i, j int) bool {
+		if report.Weeks[i].WeekStart != report.Weeks[j].WeekStart {
+			return report.Weeks[i].WeekStart < report.Weeks[j].WeekStart
+		}
+		return projectIDLess(report.Weeks[i].ProjectID, report.Weeks[j].ProjectID)
+	})
+
+	return report, nil
+}
+
+// projectIDLess сравнивает идентификаторы проектов как числа, пустой идентификатор (без проекта) - первый
+func projectIDLess(a, b string) bool {
+	x, _ := strconv.ParseInt(a, 10, 64)
+	y, _ := strconv.ParseInt(b, 10, 64)
+	return x < y
+}
+
+// secondsToMinutes переводит секунды в минуты с округлением до ближайшего целого
+func secondsToMinutes(seconds int64) int64 {
+	return (seconds + 30) / 60
+}
@@ -0,0 +1,104 @@
+package dbutils
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"webtasksplannerexample/internal/models"
+)
+
+// TrashStore описывает корзину: удалённые и выполненные разовые задачи хранятся в ней
+// до восстановления или окончательной очистки
+type TrashStore interface {
+	// ListTrash возвращает задачи из корзины, начиная с удалённых последними
+	ListTrash() ([]models.FullTask, error)
+	// Restore возвращает задачу из корзины или ErrTaskNotFound, если в корзине её нет
+	Restore(id string) error
+	// PurgeTrash окончательно удаляет задачи, попавшие в корзину раньше before, и возвращает их количество
+	PurgeTrash(before time.Time) (int64, error)
+}
+
+func (s *SQLStore) ListTrash() ([]models.FullTask, error) {
+	rows, err := s.db.Query(s.q(`
+		SELECT `+taskColumns+`
+		FROM scheduler WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC LIMIT ?`),
+		maxRowCountLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, s.loadTags(s.db, tasks)
+}
+
+func (s *SQLStore) Restore(id string) error {
+	result, err := s.db.Exec(s.q(`UPDATE scheduler SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`), id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+func (s *SQLStore) PurgeTrash(before time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cutoff := before.UTC().Format(time.RFC3339)
+
+	// Метки, чек-листы, зависимости, комментарии и учёт времени задач удаляются каскадно (ON DELETE CASCADE).
+	// Сведения о вложениях не связаны внешним ключом и остаются до PurgeOrphanAttachments,
+	// чтобы по ним можно было удалить файлы.
+	result, err := tx.Exec(s.q(`DELETE FROM scheduler WHERE deleted_at IS NOT NULL AND deleted_at < ?`), cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
+
+// RunTrashPurge раз в interval удаляет из корзины задачи старше retention, пока не отменён ctx.
+// После каждой очистки вызываются afterPurge, например для удаления файлов вложений удалённых задач.
+// При retention <= 0 очистка отключена.
+func RunTrashPurge(ctx context.Context, store TrashStore, retention time.Duration, interval time.Duration, afterPurge ...func()) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := store.PurgeTrash(time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Ошибка очистки корзины:", err)
+		} else if purged > 0 {
+			log.Printf("Из корзины удалено задач: %d", purged)
+		}
+		for _, f := range afterPurge {
+			f()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
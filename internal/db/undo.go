@@ -0,0 +1,157 @@
+package dbutils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"webtasksplannerexample/internal/models"
+)
+
+var (
+	ErrNothingToUndo = errors.New("нет действий для отмены")
+	ErrUndoConflict  = errors.New("задача изменена после отменяемого действия")
+)
+
+// UndoStore описывает отмену действий по журналу изменений
+type UndoStore interface {
+	// UndoLast отменяет последнее неотменённое создание, изменение, перенос, выполнение или удаление задачи,
+	// совершённое actor не раньше since. Возвращает ErrNothingToUndo, если отменять нечего,
+	// и ErrUndoConflict, если после этого действия задачу изменял кто-то ещё.
+	UndoLast(actor string, since time.Time) (models.UndoResult, error)
+}
+
+func (s *SQLStore) UndoLast(actor string, since time.Time) (models.UndoResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.UndoResult{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		entry  models.AuditEntry
+		before sql.NullString
+	)
+	err = tx.QueryRow(s.q(`
+		SELECT id, task_id, action, before_data
+		FROM audit_log
+		WHERE actor = ? AND action IN (?, ?, ?, ?, ?) AND undone_at IS NULL AND created_at >= ?
+		ORDER BY created_at DESC, id DESC LIMIT 1`),
+		actor,
+		models.AuditActionCreate,
+		models.AuditActionUpdate,
+		models.AuditActionSnooze,
+		models.AuditActionDone,
+		models.AuditActionDelete,
+		since.UTC().Format(time.RFC3339),
+	).Scan(&entry.ID, &entry.TaskID, &entry.Action, &before)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.UndoResult{}, ErrNothingToUndo
+		}
+		return models.UndoResult{}, err
+	}
+	if entry.Before, err = unmarshalTaskSnapshot(before); err != nil {
+		return models.UndoResult{}, err
+	}
+
+	// Отменять можно только последнее изменение задачи, иначе будут потеряны чужие правки
+	var later int
+	if err = tx.QueryRow(s.q(`
+		SELECT COUNT(*) FROM audit_log
+		WHERE task_id = ? AND id > ? AND undone_at IS NULL AND action <> ?`),
+		entry.TaskID,
+		entry.ID,
+		models.AuditActionUndo,
+	).Scan(&later); err != nil {
+		return models.UndoResult{}, err
+	}
+	if later > 0 {
+		return models.UndoResult{}, ErrUndoConflict
+	}
+
+	current, err := s.getTaskAny(tx, entry.TaskID)
+	if err != nil {
+		return models.UndoResult{}, err
+	}
+
+	switch entry.Action {
+	case models.AuditActionCreate:
+		_, err = tx.Exec(s.q(`UPDATE scheduler SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`),
+			nowTimestamp(), entry.TaskID)
+	case models.AuditActionDelete:
+		_, err = tx.Exec(s.q(`UPDATE scheduler SET deleted_at = NULL WHERE id = ?`), entry.TaskID)
+	case models.AuditActionUpdate, models.AuditActionSnooze, models.AuditActionDone:
+		if entry.Before == nil {
+			return models.UndoResult{}, fmt.Errorf("в журнале нет состояния задачи до действия %s", entry.Action)
+		}
+		err = s.restoreTaskSnapshot(tx, *entry.Before)
+		// Выполнение повторяющейся задачи снимает её зависимости, отмена выполнения возвращает их
+		if err == nil && entry.Action == models.AuditActionDone {
+			err = s.restoreDependents(tx, entry.Before.ID, entry.Before.Blocks)
+		}
+	}
+	if err != nil {
+		return models.UndoResult{}, err
+	}
+
+	if _, err = tx.Exec(s.q(`UPDATE audit_log SET undone_at = ? WHERE id = ?`), nowTimestamp(), entry.ID); err != nil {
+		return models.UndoResult{}, err
+	}
+
+	restored, err := s.getTaskAny(tx, entry.TaskID)
+	if err != nil {
+		return models.UndoResult{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.UndoResult{}, err
+	}
+
+	return models.UndoResult{Action: entry.Action, Before: current, Task: restored}, nil
+}
+
+// restoreTaskSnapshot возвращает задаче состояние из журнала изменений, в том числе достаёт её из корзины.
+// Снимок описывает задачу полностью, поэтому отсутствие меток в нём означает, что меток не было.
+func (s *SQLStore) restoreTaskSnapshot(tx *sql.Tx, task models.FullTask) error {
+	projectID, err := projectIDValue(task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// Если проект с тех пор удалён, задача восстанавливается без проекта
+	result, err := tx.Exec(s.q(`UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ?, deleted_at = NULL,
+		project_id = (SELECT id FROM projects WHERE id = ?), priority = ?, status = COALESCE(NULLIF(?, ''), status),
+		estimate_minutes = ?
+		WHERE id = ?`),
+		task.Date,
+		task.Title,
+		task.Comment,
+		task.Repeat,
+		projectID,
+		optionalInt(task.Priority),
+		task.Status,
+		optionalInt(task.Estimate),
+		task.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAffected(result); err != nil {
+		return err
+	}
+
+	taskID, err := strconv.ParseInt(task.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if err = s.setTaskTags(tx, taskID, task.Tags); err != nil {
+		return err
+	}
+
+	return s.restoreChecklist(tx, task.ID, task.Checklist)
+}
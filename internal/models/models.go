@@ -1,9 +1,16 @@
 package models
 
+import "time"
+
 type ServiceConfig struct {
 	DbFilePath     string
+	DbDSN          string // Строка подключения к PostgreSQL, при пустом значении используется SQLite
 	HTTPServerPort int
 	HTTPWebDir     string
+	TrashRetention time.Duration // Срок хранения задач в корзине, 0 - без автоматической очистки
+	UndoWindow     time.Duration // Насколько давнее действие можно отменить через POST /api/undo
+	AttachmentsDir string        // Каталог для файлов вложений
+	AttachmentMax  int64         // Максимальный размер вложения в байтах
 }
 
 type Task struct {
@@ -11,17 +18,209 @@ type Task struct {
 	Title   string `json:"title"`
 	Comment string `json:"comment,omitempty"` // Опциональный параметр
 	Repeat  string `json:"repeat,omitempty"`  // Опциональный параметр
+	// Метки задачи. При изменении задачи отсутствие поля оставляет метки как есть,
+	// пустой список удаляет все метки
+	Tags []string `json:"tags,omitempty"`
+	// Проект задачи. При изменении задачи отсутствие поля оставляет проект как есть,
+	// пустая строка убирает задачу из проекта
+	ProjectID *string `json:"project_id,omitempty"`
+	// Приоритет от 1 (наивысший) до 4, 0 снимает приоритет.
+	// При изменении задачи отсутствие поля оставляет приоритет как есть
+	Priority *int `json:"priority,omitempty"`
+	// Статус задачи на доске, при создании по умолчанию todo.
+	// При изменении задачи пустое значение оставляет статус как есть
+	Status string `json:"status,omitempty"`
+	// Оценка трудоёмкости в минутах, 0 снимает оценку.
+	// При изменении задачи отсутствие поля оставляет оценку как есть
+	Estimate *int `json:"estimate,omitempty"`
 }
 
 type FullTask struct {
 	ID string `json:"id"`
 	Task
+	DeletedAt string `json:"deleted_at,omitempty"` // Время перемещения в корзину
+	CreatedAt string `json:"created_at,omitempty"` // Время создания, пустое у задач, созданных до его учёта
+	// Чек-лист задачи, заполняется только при получении одной задачи
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+	// Идентификаторы невыполненных задач, которые блокируют эту задачу
+	BlockedBy []string `json:"blocked_by,omitempty"`
+	// Идентификаторы задач, которые ждут эту задачу, заполняется только при получении одной задачи
+	Blocks []string `json:"blocks,omitempty"`
+	// Количество комментариев в обсуждении задачи
+	CommentCount int `json:"comment_count,omitempty"`
+}
+
+// Комментарий в обсуждении задачи
+type TaskComment struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"task_id"`
+	Author    string `json:"author"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at,omitempty"` // Время последнего изменения, пустое у неизменённых
+}
+
+type TaskCommentsList struct {
+	Comments []TaskComment `json:"comments"`
+}
+
+// Пункт чек-листа задачи
+type ChecklistItem struct {
+	ID       string `json:"id"`
+	TaskID   string `json:"task_id"`
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+	Position int    `json:"position"`
+}
+
+type ChecklistList struct {
+	Items []ChecklistItem `json:"items"`
+}
+
+// Параметры отбора задач для списка
+type TaskFilter struct {
+	Search    string // Подстрока для поиска в заголовке и комментарии
+	Date      string // Дата в формате 20060102
+	Tag       string // Метка, которой должна быть отмечена задача
+	ProjectID string // Проект, к которому относится задача
+	Sort      TaskSort
+}
+
+// Поля, по которым можно упорядочить список задач
+const (
+	TaskSortDate     = "date"
+	TaskSortPriority = "priority"
+	TaskSortTitle    = "title"
+	TaskSortCreated  = "created"
+)
+
+// Порядок списка задач. Пустое поле означает сортировку по дате.
+// При равенстве значений задачи всегда упорядочиваются по возрастанию идентификатора.
+type TaskSort struct {
+	Field string
+	Desc  bool
 }
 
 type TasksList struct {
 	Tasks []FullTask `json:"tasks"`
 }
 
+// Метка и количество отмеченных ею задач
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagsList struct {
+	Tags []TagCount `json:"tags"`
+}
+
+// Вложение задачи. Содержимое хранится в каталоге вложений под именем StorageName.
+type Attachment struct {
+	ID          string `json:"id"`
+	TaskID      string `json:"task_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	StorageName string `json:"-"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type AttachmentsList struct {
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Запись учёта времени по задаче. У запущенного таймера EndedAt пустой.
+type TimeEntry struct {
+	ID              string `json:"id"`
+	TaskID          string `json:"task_id"`
+	Actor           string `json:"actor"`
+	StartedAt       string `json:"started_at"`
+	EndedAt         string `json:"ended_at,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
+
+type TimeEntriesList struct {
+	Entries []TimeEntry `json:"entries"`
+}
+
+// Параметры отчёта по времени
+type TimeReportFilter struct {
+	ProjectID string // Только задачи проекта
+	From      string // Нижняя граница начала записи в формате RFC3339, включительно
+	To        string // Верхняя граница начала записи в формате RFC3339, не включительно
+}
+
+// Учтённое и оценочное время по проекту. Оценка суммируется по задачам, по которым за период учтено время.
+// Задачи без проекта собраны в строку с пустым ProjectID.
+type ProjectTimeSummary struct {
+	ProjectID       string `json:"project_id"`
+	ProjectName     string `json:"project_name"`
+	TrackedMinutes  int64  `json:"tracked_minutes"`
+	EstimateMinutes int64  `json:"estimate_minutes"`
+}
+
+// Учтённое время по проекту за неделю, WeekStart - понедельник недели в формате 20060102
+type WeekTimeSummary struct {
+	WeekStart      string `json:"week_start"`
+	ProjectID      string `json:"project_id"`
+	TrackedMinutes int64  `json:"tracked_minutes"`
+}
+
+type TimeReport struct {
+	Projects []ProjectTimeSummary `json:"projects"`
+	Weeks    []WeekTimeSummary    `json:"weeks"`
+}
+
+// Статусы задач на доске
+const (
+	TaskStatusTodo       = "todo"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusWaiting    = "waiting"
+	TaskStatusDone       = "done"
+)
+
+// TaskStatuses - все статусы задач в порядке колонок доски по умолчанию
+var TaskStatuses = []string{TaskStatusTodo, TaskStatusInProgress, TaskStatusWaiting, TaskStatusDone}
+
+// Колонка доски проекта: статус и его отображаемое название
+type ProjectColumn struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+}
+
+type ProjectColumnsList struct {
+	Columns []ProjectColumn `json:"columns"`
+}
+
+// Колонка доски с задачами по порядку
+type BoardColumn struct {
+	ProjectColumn
+	Tasks []FullTask `json:"tasks"`
+}
+
+type Board struct {
+	Columns []BoardColumn `json:"columns"`
+}
+
+// Перемещение задачи на доске: новый статус и позиция в его колонке, начиная с 0
+type TaskMove struct {
+	Status   string `json:"status"`
+	Position int    `json:"position"`
+}
+
+// Проект (список), объединяющий задачи одного направления работы
+type Project struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Color    string `json:"color"` // Цвет в формате #rrggbb, может быть пустым
+	Archived bool   `json:"archived"`
+}
+
+type ProjectsList struct {
+	Projects []Project `json:"projects"`
+}
+
 type HTTPJSONResponseID struct {
 	ID int64 `json:"id"`
 }
@@ -29,3 +228,172 @@ type HTTPJSONResponseID struct {
 type HTTPJSONErrorMessageResponse struct {
 	Error string `json:"error"`
 }
+
+// Действия с задачами, фиксируемые в журнале изменений
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDone    = "done"
+	AuditActionDelete  = "delete"
+	AuditActionSnooze  = "snooze"
+	AuditActionRestore = "restore"
+	AuditActionUndo    = "undo"
+)
+
+// Запись журнала изменений задачи. Before и After - состояние задачи до и после изменения,
+// отсутствуют, если задачи до изменения ещё не было или после него она оказалась в корзине.
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Before    *FullTask `json:"before,omitempty"`
+	After     *FullTask `json:"after,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt string    `json:"created_at"`
+}
+
+// Параметры отбора записей журнала изменений
+type AuditFilter struct {
+	TaskID string
+	Actor  string
+	Action string
+	From   string // Нижняя граница времени записи в формате RFC3339, включительно
+	To     string // Верхняя граница времени записи в формате RFC3339, не включительно
+	Limit  int    // Не больше 500, 0 - 50 записей
+}
+
+type AuditList struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// Результат отмены действия: Action - отменённое действие, Before и Task - задача до и после отмены
+type UndoResult struct {
+	Action string
+	Before FullTask
+	Task   FullTask
+}
+
+type HTTPJSONUndoResponse struct {
+	Undone string   `json:"undone"`
+	Task   FullTask `json:"task"`
+}
+
+// Результат быстрого добавления: созданная задача и фрагменты строки, которые не удалось разобрать
+type QuickAddResult struct {
+	ID       string   `json:"id"`
+	Task     Task     `json:"task"`
+	Unparsed []string `json:"unparsed"`
+}
+
+// Шаблон задачи: по нему создаются задачи с заданными полями, метками и чек-листом.
+// DateOffset - через сколько дней после даты создания ставится задача.
+type TaskTemplate struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Comment    string   `json:"comment"`
+	Repeat     string   `json:"repeat"`
+	Tags       []string `json:"tags"`
+	Checklist  []string `json:"checklist"`
+	DateOffset int      `json:"date_offset"`
+}
+
+type TaskTemplatesList struct {
+	Templates []TaskTemplate `json:"templates"`
+}
+
+// Параметры создания задач по шаблону. Date - дата, от которой отсчитывается DateOffset шаблона
+// (по умолчанию сегодня), Count - сколько задач создать (по умолчанию одну).
+type TemplateInstantiate struct {
+	Date      string  `json:"date"`
+	Count     int     `json:"count"`
+	ProjectID *string `json:"project_id"`
+}
+
+// Идентификаторы задач, созданных по шаблону
+type TemplateTasks struct {
+	IDs []string `json:"ids"`
+}
+
+// Операции пакетной обработки задач
+const (
+	BulkOpDone   = "done"   // Выполнить задачу
+	BulkOpDelete = "delete" // Переместить задачу в корзину
+	BulkOpMove   = "move"   // Перенести задачу на дату Date или отложить на период By
+	BulkOpTag    = "tag"    // Добавить задаче метку Tag
+)
+
+// Режимы пакетной обработки
+const (
+	BulkModeAtomic     = "all-or-nothing" // При ошибке в любой операции не применяется ни одна
+	BulkModeBestEffort = "best-effort"    // Применяются все операции, которые удалось выполнить
+)
+
+// Операция пакетной обработки над задачей ID
+type BulkOperation struct {
+	Op    string `json:"op"`
+	ID    string `json:"id"`
+	Date  string `json:"date,omitempty"`  // Для move: новая дата в формате 20060102
+	By    string `json:"by,omitempty"`    // Для move: период, на который откладывается задача (1d, 3d, 1w, next-monday)
+	Tag   string `json:"tag,omitempty"`   // Для tag: добавляемая метка
+	Force bool   `json:"force,omitempty"` // Для done: выполнить задачу, несмотря на блокирующие задачи
+}
+
+type BulkRequest struct {
+	Mode       string          `json:"mode"` // По умолчанию all-or-nothing
+	Operations []BulkOperation `json:"operations"`
+}
+
+// Результат операции пакетной обработки, Index - её номер в запросе
+type BulkResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode    string       `json:"mode"`
+	Applied int          `json:"applied"` // Сколько операций применено
+	Results []BulkResult `json:"results"`
+}
+
+// Задача в выгрузке: поля задачи, как в GET /api/task, и краткая история изменений из журнала
+type ExportTask struct {
+	FullTask
+	History []ExportHistoryEntry `json:"history,omitempty"`
+}
+
+// Запись истории задачи в выгрузке
+type ExportHistoryEntry struct {
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Результат импорта строки Row (нумерация с 1, без строки заголовков CSV)
+type ImportResult struct {
+	Row     int    `json:"row"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"` // Строка пропущена: задача из корзины или не задача в выгрузке Todoist
+	ID      string `json:"id,omitempty"`      // Идентификатор созданной задачи, пустой в режиме dry_run
+	Task    *Task  `json:"task,omitempty"`    // Задача в том виде, в котором она создана или была бы создана
+	Error   string `json:"error,omitempty"`   // Ошибка или причина пропуска строки
+}
+
+type ImportResponse struct {
+	Format  string         `json:"format"`
+	DryRun  bool           `json:"dry_run"`
+	Valid   int            `json:"valid"`   // Сколько строк прошли проверку
+	Created int            `json:"created"` // Сколько задач создано, 0 в режиме dry_run
+	Failed  int            `json:"failed"`
+	Skipped int            `json:"skipped"`
+	Rows    []ImportResult `json:"rows"`
+}
+
+// Токен календарной подписки. Токен показывается только при выпуске, URL - путь ленты задач с этим токеном
+type CalendarToken struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
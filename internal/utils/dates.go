@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Периоды, на которые можно отложить задачу
+const (
+	SnoozeDay        = "1d"
+	SnoozeThreeDays  = "3d"
+	SnoozeWeek       = "1w"
+	SnoozeNextMonday = "next-monday"
+)
+
+// WeekStart возвращает начало (00:00) понедельника недели, в которую попадает t
+func WeekStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
+
+// NormalizeDate применяет к дате задачи общие правила создания и изменения задач:
+//   - пустая дата заменяется сегодняшней;
+//   - дата должна быть в формате 20060102, правило повторения (если задано) - в формате поля repeat;
+//   - сегодняшняя и будущая даты сохраняются как есть, с правилом повторения и без него;
+//   - прошедшая дата разовой задачи заменяется сегодняшней, повторяющейся - следующей по правилу после now.
+func NormalizeDate(now time.Time, date string, repeat string) (string, error) {
+	today, _ := time.Parse(DateFormat, now.Format(DateFormat))
+	if date == "" {
+		return today.Format(DateFormat), nil
+	}
+
+	start, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return "", errors.New("Дата имеет неверный формат")
+	}
+
+	if repeat == "" {
+		if start.Before(today) {
+			return today.Format(DateFormat), nil
+		}
+		return date, nil
+	}
+
+	// Правило повторения проверяется и для будущей даты
+	nextDate, err := NextDate(today, date, repeat)
+	if err != nil {
+		return "", err
+	}
+	if start.Before(today) {
+		return nextDate, nil
+	}
+	return date, nil
+}
+
+// FirstDate возвращает первую дату по правилу repeat, не раньше date и сегодняшнего дня now.
+// По правилам d и y подходит любая дата, поэтому возвращается сама date (или сегодняшний день, если она прошла),
+// по правилам w и m - ближайший подходящий день недели или месяца.
+func FirstDate(now time.Time, date string, repeat string) (string, error) {
+	start, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return "", err
+	}
+	today, _ := time.Parse(DateFormat, now.Format(DateFormat))
+	if start.Before(today) {
+		start = today
+	}
+
+	if !strings.HasPrefix(repeat, "w ") && !strings.HasPrefix(repeat, "m ") {
+		if _, err := NextDate(start, start.Format(DateFormat), repeat); err != nil {
+			return "", err
+		}
+		return start.Format(DateFormat), nil
+	}
+
+	// NextDate ищет дату строго после now, поэтому поиск начинается с предыдущего дня
+	previous := start.AddDate(0, 0, -1)
+	return NextDate(previous, previous.Format(DateFormat), repeat)
+}
+
+// SnoozeDate возвращает дату, на которую переносится задача с датой date при откладывании на период by.
+// Просроченная задача откладывается от сегодняшнего дня now, а не от своей даты.
+func SnoozeDate(now time.Time, date string, by string) (string, error) {
+	start, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return "", err
+	}
+	today, _ := time.Parse(DateFormat, now.Format(DateFormat))
+	if start.Before(today) {
+		start = today
+	}
+
+	switch by {
+	case SnoozeDay:
+		start = start.AddDate(0, 0, 1)
+	case SnoozeThreeDays:
+		start = start.AddDate(0, 0, 3)
+	case SnoozeWeek:
+		start = start.AddDate(0, 0, 7)
+	case SnoozeNextMonday:
+		start = WeekStart(start).AddDate(0, 0, 7)
+	default:
+		return "", fmt.Errorf("неверный период переноса %q, допустимы %s, %s, %s, %s",
+			by, SnoozeDay, SnoozeThreeDays, SnoozeWeek, SnoozeNextMonday)
+	}
+
+	return start.Format(DateFormat), nil
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Дни недели в правилах повторения iCalendar, с понедельника (1) по воскресенье (7)
+var icalWeekdays = []string{"", "MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// Максимальная длина строки iCalendar в байтах без перевода строки (RFC 5545, 3.1)
+const icalLineLength = 75
+
+// RepeatRRule переводит правило повторения в формате поля repeat в значение RRULE iCalendar (RFC 5545):
+// "d 3" - FREQ=DAILY;INTERVAL=3, "w 1,5" - FREQ=WEEKLY;BYDAY=MO,FR, "m 1,-1" - FREQ=MONTHLY;BYMONTHDAY=1,-1,
+// "m 5 1,6" - FREQ=YEARLY;BYMONTH=1,6;BYMONTHDAY=5, "y" - FREQ=YEARLY. Пустое правило - пустое значение.
+func RepeatRRule(repeat string) (string, error) {
+	if repeat == "" {
+		return "", nil
+	}
+	if !IsValidFormat(repeat, RepeatValidFormats) {
+		return "", fmt.Errorf("некорректный формат repeat")
+	}
+
+	parts := strings.Split(repeat, " ")
+	switch parts[0] {
+	case "y":
+		return "FREQ=YEARLY", nil
+	case "d":
+		if parts[1] == "1" {
+			return "FREQ=DAILY", nil
+		}
+		return "FREQ=DAILY;INTERVAL=" + parts[1], nil
+	case "w":
+		days, err := StringSliceToIntSortAndRemoveDuplicates(strings.Split(parts[1], ","))
+		if err != nil {
+			return "", err
+		}
+		byDay := make([]string, len(days))
+		for i, day := range days {
+			byDay[i] = icalWeekdays[day]
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(byDay, ","), nil
+	}
+
+	// Правило "m": дни месяца и, возможно, месяцы. Числа могут быть записаны с ведущим нулём
+	byMonthDay, err := icalNumberList(parts[1])
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 2 {
+		return "FREQ=MONTHLY;BYMONTHDAY=" + byMonthDay, nil
+	}
+	byMonth, err := icalNumberList(parts[2])
+	if err != nil {
+		return "", err
+	}
+	return "FREQ=YEARLY;BYMONTH=" + byMonth + ";BYMONTHDAY=" + byMonthDay, nil
+}
+
+// icalNumberList приводит список чисел через запятую к виду без ведущих нулей и повторов, по возрастанию
+func icalNumberList(list string) (string, error) {
+	numbers, err := StringSliceToIntSortAndRemoveDuplicates(strings.Split(list, ","))
+	if err != nil {
+		return "", err
+	}
+	result := make([]string, len(numbers))
+	for i, n := range numbers {
+		result[i] = strconv.Itoa(n)
+	}
+	return strings.Join(result, ","), nil
+}
+
+// ICalText экранирует значение текстового свойства iCalendar: обратную косую черту, запятую,
+// точку с запятой и переводы строк
+func ICalText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+// ICalLine возвращает строку свойства iCalendar с переводом строки CRLF, перенося строки длиннее
+// 75 байт: продолжение начинается с пробела, символы UTF-8 не разрываются
+func ICalLine(line string) string {
+	var b strings.Builder
+
+	limit := icalLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Пробел в начале строки продолжения входит в её длину
+		limit = icalLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
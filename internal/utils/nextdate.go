@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DateFormat string = "20060102"
+)
+
+// Слайс из валидных регэкспов для поля repeat
+var RepeatValidFormats = []string{
+	`^y$`, // Формат "y"
+	`^d\s(([1-9])|([1-9]\d)|[1-3]\d{2}|(400))$`, // Формат "d <число от 1 до 400>"
+	`^w\s[1-7](,[1-7]){0,6}$`,                   // Формат "w <числа от 1 до 7 через запятую>, при этом не более 7 штук
+	`^m\s((\-[12])|(0?[1-9])|([12]\d)|(3[01]))((,\-[12])|(,[1-9])|(,[12]\d)|(,3[01])){0,30}(\s((0?[1-9])|(1[012]))((,[1-9])|(,1[012])){0,11})?$`,
+	// Формат "m <числа от 1 до 31 через запятую>, при этом не более 31 штуки, числа 1 - 9 могут иметь написание 01 02 и т.д до 09
+	// далее опционально через пробел <числа от 1 до 12 через запятую> не более 12 штук
+}
+
+// Функция для подсчета даты по правилам повтора
+func NextDate(now time.Time, date string, repeat string) (string, error) {
+	startDate, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return "", err
+	}
+	if repeat == "" {
+		return "", fmt.Errorf("пустое значение repeat")
+	}
+	//валидируем repeat переменную
+	if !(IsValidFormat(repeat, RepeatValidFormats)) {
+		return "", fmt.Errorf("некорректный формат repeat")
+	}
+
+	substrs := strings.Split(repeat, " ")
+	switch substrs[0] {
+	case "y":
+		nextDate := startDate.AddDate(1, 0, 0)
+		for nextDate.Before(now) || nextDate.Equal(now) {
+			nextDate = nextDate.AddDate(1, 0, 0)
+		}
+		return nextDate.Format(DateFormat), nil
+	case "d":
+		days, _ := strconv.Atoi(substrs[1])
+		nextDate := startDate.AddDate(0, 0, days)
+		for nextDate.Before(now) || nextDate.Equal(now) {
+			nextDate = nextDate.AddDate(0, 0, days)
+		}
+		return nextDate.Format(DateFormat), nil
+	case "w":
+		repeatdays, err := StringToInt(strings.Split(substrs[1], ","))
+		if err != nil {
+			return "", fmt.Errorf("неподдерживаемый формат")
+		}
+		var nextDateSlice []time.Time
+		for i := 0; i < len(repeatdays); i++ {
+			closestDate := startDate
+			if closestDate = GetClosestWeekday(repeatdays[i], startDate); closestDate.Before(now) || closestDate.Equal(now) {
+				closestDate = GetClosestWeekday(repeatdays[i], now)
+			}
+			nextDateSlice = append(nextDateSlice, closestDate)
+		}
+		return FindMinDate(nextDateSlice).Format(DateFormat), nil
+	case "m":
+		if len(substrs) == 2 {
+			repeatmonthdays, err := StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[1], ","))
+			if err != nil {
+				return "", fmt.Errorf("неподдерживаемый формат")
+			}
+			var nextDateSlice []time.Time
+			for i := 0; i < len(repeatmonthdays); i++ {
+				closestDate := startDate
+				if closestDate = GetClosesDateOfMonth(repeatmonthdays[i], int(startDate.Month()), startDate); closestDate.Before(now) || closestDate.Equal(now) {
+					if closestDate = GetClosesDateOfMonth(repeatmonthdays[i], int(now.Month()), now); closestDate.Before(now) || closestDate.Equal(now) {
+						closestDate = GetClosesDateOfMonth(repeatmonthdays[i], int(now.Month())+1, now)
+					}
+				}
+				nextDateSlice = append(nextDateSlice, closestDate)
+			}
+			return FindMinDate(nextDateSlice).Format(DateFormat), nil
+		} else if len(substrs) == 3 {
+
+			repeatmonthdays, err := StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[1], ","))
+			if err != nil {
+				return "", fmt.Errorf("неподдерживаемый формат")
+			}
+			repeatmonths, err := StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[2], ","))
+			if err != nil {
+				return "", fmt.Errorf("неподдерживаемый формат")
+			}
+			var nextDateSlice []time.Time
+			for i := 0; i < len(repeatmonthdays); i++ {
+				for j := 0; j < len(repeatmonths); j++ {
+					closestDate := startDate
+					if closestDate = GetDateOfMonth(repeatmonthdays[i], repeatmonths[j], now, startDate); closestDate.Before(now) || closestDate.Equal(now) {
+						continue
+					} else {
+						nextDateSlice = append(nextDateSlice, closestDate)
+					}
+				}
+			}
+			return FindMinDate(nextDateSlice).Format(DateFormat), nil
+		}
+
+	default:
+		return "", fmt.Errorf("неподдерживаемый формат")
+	}
+	return "", fmt.Errorf("unexpected error")
+}
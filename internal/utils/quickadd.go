@@ -0,0 +1,481 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuickTask - задача, разобранная из строки быстрого добавления
+type QuickTask struct {
+	Title    string
+	Date     string // Дата в формате 20060102, пустая - не указана
+	Repeat   string // Правило повторения в формате поля repeat
+	Tags     []string
+	Priority int      // 0 - не указан
+	Unparsed []string // Фрагменты, похожие на указания даты, повтора, метки или приоритета, которые не удалось разобрать
+}
+
+// Единицы повторения
+const (
+	quickUnitDay   = "d"
+	quickUnitWeek  = "w"
+	quickUnitMonth = "m"
+	quickUnitYear  = "y"
+)
+
+var (
+	quickWeekdays = map[string]int{
+		"понедельник": 1, "понедельникам": 1, "пн": 1, "monday": 1, "mondays": 1,
+		"вторник": 2, "вторникам": 2, "вт": 2, "tuesday": 2, "tuesdays": 2,
+		"среда": 3, "среду": 3, "средам": 3, "ср": 3, "wednesday": 3, "wednesdays": 3,
+		"четверг": 4, "четвергам": 4, "чт": 4, "thursday": 4, "thursdays": 4,
+		"пятница": 5, "пятницу": 5, "пятницам": 5, "пт": 5, "friday": 5, "fridays": 5,
+		"суббота": 6, "субботу": 6, "субботам": 6, "сб": 6, "saturday": 6, "saturdays": 6,
+		"воскресенье": 7, "воскресеньям": 7, "вс": 7, "sunday": 7, "sundays": 7,
+	}
+
+	quickUnits = map[string]string{
+		"день": quickUnitDay, "дня": quickUnitDay, "дней": quickUnitDay, "дни": quickUnitDay,
+		"day": quickUnitDay, "days": quickUnitDay,
+		"неделя": quickUnitWeek, "неделю": quickUnitWeek, "недели": quickUnitWeek, "недель": quickUnitWeek,
+		"week": quickUnitWeek, "weeks": quickUnitWeek,
+		"месяц": quickUnitMonth, "месяца": quickUnitMonth, "месяцев": quickUnitMonth,
+		"month": quickUnitMonth, "months": quickUnitMonth,
+		"год": quickUnitYear, "года": quickUnitYear, "лет": quickUnitYear,
+		"year": quickUnitYear, "years": quickUnitYear,
+	}
+
+	// Слова, после которых идёт период повторения
+	quickEveryWords = []string{"каждый", "каждую", "каждое", "каждые", "каждого", "каждой", "every", "each"}
+
+	// Слова после периода, при которых период указывает на прошлое, а не на повтор: "each 2 days ago"
+	quickAgoWords = map[string]bool{"ago": true, "назад": true}
+
+	// Однословные правила повторения
+	quickRepeatWords = map[string]string{
+		"ежедневно": quickUnitDay, "daily": quickUnitDay,
+		"еженедельно": quickUnitWeek, "weekly": quickUnitWeek,
+		"ежемесячно": quickUnitMonth, "monthly": quickUnitMonth,
+		"ежегодно": quickUnitYear, "yearly": quickUnitYear, "annually": quickUnitYear,
+	}
+
+	quickPriorityRegexp   = regexp.MustCompile(`^!(\d+)$`)
+	quickDottedDateRegexp = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{4}))?$`)
+	quickISODateRegexp    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	quickCompactDate      = regexp.MustCompile(`^\d{8}$`)
+	quickOrdinalRegexp    = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|-го|-е)$`)
+)
+
+// quickParser хранит состояние разбора строки быстрого добавления
+type quickParser struct {
+	today  time.Time
+	words  []string // Слова исходной строки
+	norm   []string // Те же слова в нижнем регистре, без ё и знаков препинания по краям
+	title  []string
+	result QuickTask
+
+	date        time.Time
+	dateSet     bool
+	repeatUnit  string
+	repeatEvery int
+	weekdays    []int
+	monthDay    int
+}
+
+// ParseQuickTask разбирает строку вида "Отчёт в пятницу каждую неделю #work !1" или
+// "pay rent every month on the 5th": дату, правило повторения, метки (#метка) и приоритет (!1 - !4).
+// Оставшиеся слова образуют заголовок. Дата и повтор без явного года отсчитываются от now.
+func ParseQuickTask(now time.Time, text string) QuickTask {
+	p := quickParser{words: strings.Fields(text)}
+	p.today, _ = time.Parse(DateFormat, now.Format(DateFormat))
+	for _, word := range p.words {
+		p.norm = append(p.norm, normalizeQuickWord(word))
+	}
+
+	for i := 0; i < len(p.words); {
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		p.title = append(p.title, p.words[i])
+		i++
+	}
+
+	p.finish()
+	return p.result
+}
+
+func normalizeQuickWord(word string) string {
+	word = strings.ToLower(word)
+	word = strings.ReplaceAll(word, "ё", "е")
+	return strings.Trim(word, ",;:.?")
+}
+
+// at возвращает нормализованное слово с номером i или пустую строку за концом строки
+func (p *quickParser) at(i int) string {
+	if i < len(p.norm) {
+		return p.norm[i]
+	}
+	return ""
+}
+
+// unparsed отмечает n слов начиная с i как неразобранный фрагмент
+func (p *quickParser) unparsed(i, n int) int {
+	p.result.Unparsed = append(p.result.Unparsed, strings.Join(p.words[i:i+n], " "))
+	return n
+}
+
+// match пробует разобрать указание, начинающееся со слова i, и возвращает количество разобранных слов
+func (p *quickParser) match(i int) int {
+	word := p.at(i)
+
+	if strings.HasPrefix(p.words[i], "#") {
+		tags, err := NormalizeTags([]string{p.words[i]})
+		if err != nil || len(tags) == 0 {
+			return p.unparsed(i, 1)
+		}
+		if !slices.Contains(p.result.Tags, tags[0]) {
+			p.result.Tags = append(p.result.Tags, tags[0])
+		}
+		return 1
+	}
+
+	if m := quickPriorityRegexp.FindStringSubmatch(p.words[i]); m != nil {
+		priority, _ := strconv.Atoi(m[1])
+		if priority < 1 || priority > 4 || p.result.Priority != 0 {
+			return p.unparsed(i, 1)
+		}
+		p.result.Priority = priority
+		return 1
+	}
+
+	if n := p.matchRepeat(i); n > 0 {
+		return n
+	}
+	if n := p.matchDate(i); n > 0 {
+		return n
+	}
+
+	// Предлог перед датой: "в пятницу", "во вторник", "on monday", "on the 5th"
+	if (word == "в" || word == "во" || word == "on") && i+1 < len(p.words) {
+		next := i + 1
+		if word == "on" && p.at(next) == "the" {
+			next++
+		}
+		if next < len(p.words) {
+			if n := p.matchDate(next); n > 0 {
+				return n + next - i
+			}
+		}
+	}
+
+	return 0
+}
+
+// matchRepeat разбирает правило повторения, начинающееся со слова i
+func (p *quickParser) matchRepeat(i int) int {
+	word := p.at(i)
+
+	if unit, ok := quickRepeatWords[word]; ok {
+		return p.setRepeat(i, 1, unit, 1)
+	}
+
+	// "по пятницам", "по понедельникам и средам"
+	if word == "по" {
+		if weekdays := p.matchWeekdays(i + 1); len(weekdays) > 0 {
+			return p.setWeekdays(i, len(weekdays)+1, weekdays)
+		}
+		return 0
+	}
+
+	if !slices.Contains(quickEveryWords, word) {
+		return 0
+	}
+
+	// Слова вроде "every" и "каждый" встречаются и в обычном тексте ("read every book", "каждый охотник"),
+	// поэтому без распознанного периода они остаются в заголовке. Неразобранным фрагментом считается только
+	// явное, но неподдерживаемое правило, например "каждые 3 месяца".
+	next := p.at(i + 1)
+	if next == "other" || next == "второй" {
+		if unit, ok := quickUnits[p.at(i+2)]; ok && !quickAgoWords[p.at(i+3)] {
+			return p.setRepeat(i, 3, unit, 2)
+		}
+		return 0
+	}
+
+	if n, err := strconv.Atoi(next); err == nil {
+		if unit, ok := quickUnits[p.at(i+2)]; ok && !quickAgoWords[p.at(i+3)] {
+			return p.setRepeat(i, 3, unit, n)
+		}
+		// "каждое 5 число"
+		if strings.HasPrefix(p.at(i+2), "числ") {
+			return p.setMonthDay(i, 3, n, true)
+		}
+		return 0
+	}
+
+	// "every 5th"
+	if m := quickOrdinalRegexp.FindStringSubmatch(next); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		return p.setMonthDay(i, 2, day, true)
+	}
+
+	if unit, ok := quickUnits[next]; ok {
+		return p.setRepeat(i, 2, unit, 1)
+	}
+
+	if weekdays := p.matchWeekdays(i + 1); len(weekdays) > 0 {
+		return p.setWeekdays(i, len(weekdays)+1, weekdays)
+	}
+
+	return 0
+}
+
+// matchWeekdays разбирает список дней недели ("понедельник и среду", "monday, friday") начиная со слова i.
+// Возвращает по одному элементу на каждое разобранное слово, включая союзы, 0 на месте союза.
+func (p *quickParser) matchWeekdays(i int) []int {
+	var weekdays []int
+	for {
+		weekday, ok := quickWeekdays[p.at(i+len(weekdays))]
+		if !ok {
+			return weekdays
+		}
+		weekdays = append(weekdays, weekday)
+		if sep := p.at(i + len(weekdays)); (sep == "и" || sep == "and") && quickWeekdays[p.at(i+len(weekdays)+1)] != 0 {
+			weekdays = append(weekdays, 0)
+		}
+	}
+}
+
+// setWeekdays запоминает еженедельный повтор по дням weekdays, заданный n словами начиная с i
+func (p *quickParser) setWeekdays(i, n int, weekdays []int) int {
+	if p.repeatUnit != "" {
+		return p.unparsed(i, n)
+	}
+	for _, weekday := range weekdays {
+		if weekday != 0 && !slices.Contains(p.weekdays, weekday) {
+			p.weekdays = append(p.weekdays, weekday)
+		}
+	}
+	p.repeatUnit, p.repeatEvery = quickUnitWeek, 1
+	return n
+}
+
+// setRepeat запоминает правило повторения "каждые every единиц unit", заданное n словами начиная с i
+func (p *quickParser) setRepeat(i, n int, unit string, every int) int {
+	if p.repeatUnit != "" {
+		return p.unparsed(i, n)
+	}
+
+	switch {
+	case unit == quickUnitWeek && every > 1:
+		// Повтор раз в несколько недель выражается через дни
+		unit, every = quickUnitDay, every*7
+	case unit != quickUnitDay && every != 1:
+		return p.unparsed(i, n)
+	}
+	if every < 1 || every > 400 {
+		return p.unparsed(i, n)
+	}
+
+	p.repeatUnit, p.repeatEvery = unit, every
+	return n
+}
+
+// setMonthDay запоминает день месяца; monthly - день задан вместе с ежемесячным повтором
+func (p *quickParser) setMonthDay(i, n int, day int, monthly bool) int {
+	if day < 1 || day > 31 || p.monthDay != 0 || (monthly && p.repeatUnit != "") {
+		return p.unparsed(i, n)
+	}
+	p.monthDay = day
+	if monthly {
+		p.repeatUnit, p.repeatEvery = quickUnitMonth, 1
+	}
+	return n
+}
+
+// setDate запоминает дату задачи, заданную n словами начиная с i
+func (p *quickParser) setDate(i, n int, date time.Time) int {
+	if p.dateSet || date.Before(p.today) {
+		return p.unparsed(i, n)
+	}
+	p.date, p.dateSet = date, true
+	return n
+}
+
+// matchDate разбирает дату, начинающуюся со слова i
+func (p *quickParser) matchDate(i int) int {
+	word := p.at(i)
+
+	switch word {
+	case "сегодня", "today":
+		return p.setDate(i, 1, p.today)
+	case "завтра", "tomorrow":
+		return p.setDate(i, 1, p.today.AddDate(0, 0, 1))
+	case "послезавтра":
+		return p.setDate(i, 1, p.today.AddDate(0, 0, 2))
+	}
+
+	if weekday, ok := quickWeekdays[word]; ok {
+		return p.setDate(i, 1, nextWeekday(p.today, weekday, false))
+	}
+
+	// "next monday", "next week", "на следующей неделе"
+	if word == "next" {
+		if weekday, ok := quickWeekdays[p.at(i+1)]; ok {
+			return p.setDate(i, 2, nextWeekday(p.today, weekday, true))
+		}
+		if quickUnits[p.at(i+1)] == quickUnitWeek {
+			return p.setDate(i, 2, WeekStart(p.today).AddDate(0, 0, 7))
+		}
+		return 0
+	}
+	if word == "на" && p.at(i+1) == "следующей" && p.at(i+2) == "неделе" {
+		return p.setDate(i, 3, WeekStart(p.today).AddDate(0, 0, 7))
+	}
+
+	// "через 3 дня", "через неделю", "in 2 weeks", "in a month"
+	if word == "через" || word == "in" {
+		count, unitAt := 1, i+1
+		if n, err := strconv.Atoi(p.at(i + 1)); err == nil {
+			count, unitAt = n, i+2
+		} else if p.at(i+1) == "a" || p.at(i+1) == "an" {
+			unitAt = i + 2
+		}
+		unit, ok := quickUnits[p.at(unitAt)]
+		if !ok {
+			return 0
+		}
+		n := unitAt - i + 1
+		if count < 1 || count > 400 {
+			return p.unparsed(i, n)
+		}
+		switch unit {
+		case quickUnitDay:
+			return p.setDate(i, n, p.today.AddDate(0, 0, count))
+		case quickUnitWeek:
+			return p.setDate(i, n, p.today.AddDate(0, 0, 7*count))
+		case quickUnitMonth:
+			return p.setDate(i, n, p.today.AddDate(0, count, 0))
+		default:
+			return p.setDate(i, n, p.today.AddDate(count, 0, 0))
+		}
+	}
+
+	// "5 числа", "5-го", "5-го числа", "5th"
+	if m := quickOrdinalRegexp.FindStringSubmatch(word); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		n := 1
+		if strings.HasPrefix(p.at(i+1), "числ") {
+			n = 2
+		}
+		return p.setMonthDay(i, n, day, false)
+	}
+	if day, err := strconv.Atoi(word); err == nil && strings.HasPrefix(p.at(i+1), "числ") {
+		return p.setMonthDay(i, 2, day, false)
+	}
+
+	return p.matchExplicitDate(i)
+}
+
+// matchExplicitDate разбирает дату, записанную цифрами: 20060102, 2006-01-02, 02.01.2006 или 02.01
+func (p *quickParser) matchExplicitDate(i int) int {
+	word := p.at(i)
+
+	var (
+		date time.Time
+		err  error
+	)
+	switch {
+	case quickCompactDate.MatchString(word):
+		date, err = time.Parse(DateFormat, word)
+	case quickISODateRegexp.MatchString(word):
+		date, err = time.Parse(time.DateOnly, word)
+	case quickDottedDateRegexp.MatchString(word):
+		m := quickDottedDateRegexp.FindStringSubmatch(word)
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year := p.today.Year()
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+		}
+		date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != day || int(date.Month()) != month {
+			err = fmt.Errorf("несуществующая дата %s", word)
+		} else if m[3] == "" && date.Before(p.today) {
+			// Дата без года, уже прошедшая в этом году, относится к следующему
+			date = date.AddDate(1, 0, 0)
+		}
+	default:
+		return 0
+	}
+	if err != nil {
+		return p.unparsed(i, 1)
+	}
+	return p.setDate(i, 1, date)
+}
+
+// nextWeekday возвращает ближайший день недели weekday (1 - понедельник) начиная с today,
+// при strict - строго после today
+func nextWeekday(today time.Time, weekday int, strict bool) time.Time {
+	offset := (weekday - (int(today.Weekday())+6)%7 - 1 + 7) % 7
+	if offset == 0 && strict {
+		offset = 7
+	}
+	return today.AddDate(0, 0, offset)
+}
+
+// finish собирает результат разбора: заголовок, правило повторения и дату
+func (p *quickParser) finish() {
+	p.result.Title = strings.Join(p.title, " ")
+
+	base := p.today
+	if p.dateSet {
+		base = p.date
+	}
+
+	switch p.repeatUnit {
+	case quickUnitDay:
+		p.result.Repeat = fmt.Sprintf("d %d", p.repeatEvery)
+	case quickUnitWeek:
+		if len(p.weekdays) == 0 {
+			p.weekdays = []int{(int(base.Weekday())+6)%7 + 1}
+		}
+		slices.Sort(p.weekdays)
+		days := make([]string, len(p.weekdays))
+		for i, weekday := range p.weekdays {
+			days[i] = strconv.Itoa(weekday)
+		}
+		p.result.Repeat = "w " + strings.Join(days, ",")
+	case quickUnitMonth:
+		if p.monthDay == 0 {
+			p.monthDay = base.Day()
+		}
+		p.result.Repeat = fmt.Sprintf("m %d", p.monthDay)
+	case quickUnitYear:
+		p.result.Repeat = "y"
+	}
+
+	if p.dateSet {
+		p.result.Date = p.date.Format(DateFormat)
+		return
+	}
+
+	// Без явной даты задача ставится на первое подходящее по правилу число, начиная с сегодняшнего
+	rule := ""
+	switch {
+	case p.repeatUnit == quickUnitWeek || p.repeatUnit == quickUnitMonth:
+		rule = p.result.Repeat
+	case p.monthDay != 0:
+		rule = fmt.Sprintf("m %d", p.monthDay)
+	}
+	if rule != "" {
+		if date, err := FirstDate(p.today, p.today.Format(DateFormat), rule); err == nil {
+			p.result.Date = date
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	MaxTagLength   = 32
+	MaxTagsPerTask = 20
+)
+
+// NormalizeTags приводит метки к единому виду: без начального '#', в нижнем регистре, без повторов.
+// Порядок меток сохраняется, nil остаётся nil, чтобы отличать "метки не переданы" от "меток нет".
+func NormalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" {
+			return nil, fmt.Errorf("метка не может быть пустой")
+		}
+		if len([]rune(tag)) > MaxTagLength {
+			return nil, fmt.Errorf("метка %q длиннее %d символов", tag, MaxTagLength)
+		}
+		if strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' || r == '#' }) {
+			return nil, fmt.Errorf("метка %q не должна содержать пробелы, запятые и '#'", tag)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > MaxTagsPerTask {
+		return nil, fmt.Errorf("у задачи может быть не более %d меток", MaxTagsPerTask)
+	}
+
+	return result, nil
+}
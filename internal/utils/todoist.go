@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// Время в дате Todoist ("every day at 9am", "tomorrow at 10:30"): в планировщике задачи ставятся на день
+	todoistTimeRegexp = regexp.MustCompile(`(?i)\s+at\s+\d{1,2}(:\d{2})?\s*(am|pm)?$`)
+	// Дата Todoist без повтора: 2024-01-05 или 2024-01-05T10:00:00 (с часовым поясом или без)
+	todoistISODateRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(T[\d:.]+Z?)?$`)
+	// Рабочие дни в повторе Todoist
+	todoistWorkdaysRegexp = regexp.MustCompile(`(?i)\b(weekday|workday)s?\b`)
+	// Сокращённые названия дней недели в повторе Todoist ("every mon, fri")
+	todoistWeekdayRegexp = regexp.MustCompile(`(?i)\b(mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)\b`)
+	todoistWeekdays      = map[string]string{
+		"mon": "monday", "tue": "tuesday", "tues": "tuesday", "wed": "wednesday",
+		"thu": "thursday", "thur": "thursday", "thurs": "thursday", "fri": "friday", "sat": "saturday", "sun": "sunday",
+	}
+)
+
+// ParseTodoistDate разбирает значение столбца DATE выгрузки Todoist в дату (20060102, пустая - не указана)
+// и правило повторения в формате поля repeat. Дата может быть записана как 2024-01-05 или словами на английском
+// ("tomorrow", "next monday"), повтор - словами ("every day", "every 3 days", "every other week",
+// "every mon, fri", "every weekday", "every month on the 5th", "every year"). Время ("at 9am") отбрасывается,
+// "every!" (повтор от даты выполнения) обрабатывается как "every". Если значение не удаётся разобрать целиком,
+// например "every 3 months" или "every last day", возвращается ошибка.
+func ParseTodoistDate(now time.Time, text string) (date string, repeat string, err error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", "", nil
+	}
+
+	if m := todoistISODateRegexp.FindStringSubmatch(text); m != nil {
+		parsed, err := time.Parse(time.DateOnly, m[1])
+		if err != nil {
+			return "", "", fmt.Errorf("неверная дата Todoist %q", text)
+		}
+		return parsed.Format(DateFormat), "", nil
+	}
+
+	prepared := todoistTimeRegexp.ReplaceAllString(text, "")
+	prepared = strings.ReplaceAll(prepared, "every!", "every")
+	prepared = todoistWorkdaysRegexp.ReplaceAllString(prepared, "monday, tuesday, wednesday, thursday, friday")
+	prepared = todoistWeekdayRegexp.ReplaceAllStringFunc(prepared, func(day string) string {
+		return todoistWeekdays[strings.ToLower(day)]
+	})
+
+	quick := ParseQuickTask(now, prepared)
+	if quick.Title != "" || len(quick.Unparsed) > 0 || len(quick.Tags) > 0 || quick.Priority != 0 {
+		return "", "", fmt.Errorf("не удалось разобрать дату Todoist %q", text)
+	}
+	return quick.Date, quick.Repeat, nil
+}
+
+// ParseTodoistContent разбирает значение столбца CONTENT выгрузки Todoist: метки @метка
+// возвращаются отдельно, остальные слова образуют заголовок
+func ParseTodoistContent(content string) (title string, labels []string) {
+	var words []string
+	for _, word := range strings.Fields(content) {
+		if len(word) > 1 && strings.HasPrefix(word, "@") {
+			labels = append(labels, strings.TrimPrefix(word, "@"))
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), labels
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"fmt"
 	"regexp"
 	"sort"
 	"strconv"
@@ -111,8 +110,6 @@ func GetClosesDateOfMonth(day int, currentMonth int, date time.Time) time.Time {
 	lastDayOfMonth := time.Date(currentYear, time.Month(currentMonth+1), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1).Day()
 	targetDay := lastDayOfMonth + day + 1
 
-	fmt.Println(time.Date(currentYear, time.Month(currentMonth), targetDay, 0, 0, 0, 0, time.UTC))
-
 	return time.Date(currentYear, time.Month(currentMonth), targetDay, 0, 0, 0, 0, time.UTC)
 }
 
@@ -135,7 +132,5 @@ func GetDateOfMonth(day int, currentMonth int, nowdate time.Time, date time.Time
 	lastDayOfMonth := time.Date(currentYear, time.Month(currentMonth+1), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1).Day()
 	targetDay := lastDayOfMonth + day + 1
 
-	fmt.Println(time.Date(currentYear, time.Month(currentMonth), targetDay, 0, 0, 0, 0, time.UTC))
-
 	return time.Date(currentYear, time.Month(currentMonth), targetDay, 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,185 @@
+package webserverutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	attachmentsutils "webtasksplannerexample/internal/attachments"
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+const (
+	// Поле формы multipart/form-data с файлом вложения
+	attachmentFormField   = "file"
+	maxAttachmentNameSize = 255
+	// Запас на заголовки и границы multipart сверх максимального размера файла
+	multipartOverhead = 1 << 16
+)
+
+// attachmentErrorStatus возвращает код ответа для ошибки при работе с вложениями
+func attachmentErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrAttachmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, attachmentsutils.ErrTooLarge), errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, attachmentsutils.ErrEmpty):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// getAttachmentsHandler возвращает вложения задачи task_id
+func (s *Server) getAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachments, err := s.store.ListAttachments(taskID)
+	if err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.AttachmentsList{Attachments: attachments})
+}
+
+// postAttachmentHandler сохраняет файл из поля file формы multipart/form-data как вложение задачи task_id
+func (s *Server) postAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Проверяем задачу до чтения файла, чтобы не принимать содержимое впустую
+	if _, err := s.store.Get(taskID); err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.files.MaxSize()+multipartOverhead)
+	defer r.Body.Close()
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "ожидается файл в форме multipart/form-data: "+err.Error())
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeJSONError(w, http.StatusBadRequest, "в запросе нет файла в поле "+attachmentFormField)
+			return
+		}
+		if err != nil {
+			writeJSONError(w, attachmentErrorStatus(err), err.Error())
+			return
+		}
+		if part.FormName() != attachmentFormField {
+			part.Close()
+			continue
+		}
+
+		s.saveAttachment(w, taskID, part)
+		part.Close()
+		return
+	}
+}
+
+// saveAttachment сохраняет файл и сведения о нём, а в ответ отправляет созданное вложение
+func (s *Server) saveAttachment(w http.ResponseWriter, taskID string, part *multipart.Part) {
+	fileName := strings.TrimSpace(filepath.Base(part.FileName()))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+		writeJSONError(w, http.StatusBadRequest, "не указано имя файла")
+		return
+	}
+	if len([]rune(fileName)) > maxAttachmentNameSize {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("имя файла длиннее %d символов", maxAttachmentNameSize))
+		return
+	}
+
+	attachment, err := s.files.Save(part, fileName)
+	if err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+	attachment.TaskID = taskID
+
+	id, err := s.store.AddAttachment(attachment)
+	if err != nil {
+		s.files.Remove(attachment.StorageName)
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	created, err := s.store.GetAttachment(strconv.FormatInt(id, 10))
+	if err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// downloadAttachmentHandler отдаёт содержимое вложения id как файл для скачивания
+func (s *Server) downloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachment, err := s.store.GetAttachment(id)
+	if err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	f, err := s.files.Open(attachment.StorageName)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "не удалось открыть файл вложения: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	modTime, _ := time.Parse(time.RFC3339, attachment.CreatedAt)
+
+	// Вложение всегда скачивается, а не открывается в браузере, и его тип не переопределяется браузером
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, attachment.FileName, modTime, f)
+}
+
+// deleteAttachmentHandler удаляет вложение id вместе с файлом
+func (s *Server) deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachment, err := s.store.DeleteAttachment(id)
+	if err != nil {
+		writeJSONError(w, attachmentErrorStatus(err), err.Error())
+		return
+	}
+	s.files.Remove(attachment.StorageName)
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,127 @@
+package webserverutils
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+const (
+	// Заголовок с именем пользователя, выполняющего запрос. Авторизации в приложении нет,
+	// поэтому значение принимается как есть и служит только для журнала изменений.
+	actorHeader    = "X-User"
+	anonymousActor = "anonymous"
+	maxActorLength = 64
+)
+
+// actorFromRequest возвращает имя пользователя, от имени которого выполняется запрос
+func actorFromRequest(r *http.Request) string {
+	actor := strings.TrimSpace(r.Header.Get(actorHeader))
+	if actor == "" {
+		return anonymousActor
+	}
+	if runes := []rune(actor); len(runes) > maxActorLength {
+		actor = string(runes[:maxActorLength])
+	}
+	return actor
+}
+
+// recordAudit сохраняет запись о выполненном изменении задачи.
+// Ошибка записи в журнал не отменяет уже выполненное изменение, поэтому только логируется.
+func (s *Server) recordAudit(r *http.Request, action string, taskID string, before, after *models.FullTask) {
+	entry := models.AuditEntry{
+		TaskID:    taskID,
+		Actor:     actorFromRequest(r),
+		Action:    action,
+		Before:    before,
+		After:     after,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if err := s.store.AddAuditEntry(entry); err != nil {
+		log.Printf("Ошибка записи в журнал изменений (%s задачи %s): %v", action, taskID, err)
+	}
+}
+
+// taskSnapshot возвращает текущее состояние задачи для журнала или nil, если задача недоступна
+func (s *Server) taskSnapshot(id string) *models.FullTask {
+	task, err := s.store.Get(id)
+	if err != nil {
+		return nil
+	}
+	return &task
+}
+
+// parseDateRange переводит параметры from и to (даты в формате 20060102 по UTC, to включительно)
+// в границы отметок времени RFC3339: from включительно, to не включительно. Отсутствующая граница остаётся пустой.
+func parseDateRange(r *http.Request) (string, string, error) {
+	var from, to string
+
+	if fromParam := r.URL.Query().Get("from"); fromParam != "" {
+		fromDate, err := time.Parse(utils.DateFormat, fromParam)
+		if err != nil {
+			return "", "", errors.New("параметр from имеет неверный формат")
+		}
+		from = fromDate.UTC().Format(time.RFC3339)
+	}
+
+	if toParam := r.URL.Query().Get("to"); toParam != "" {
+		toDate, err := time.Parse(utils.DateFormat, toParam)
+		if err != nil {
+			return "", "", errors.New("параметр to имеет неверный формат")
+		}
+		to = toDate.AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+	}
+
+	return from, to, nil
+}
+
+// getAuditHandler возвращает записи журнала изменений.
+// Фильтры: task_id, actor, action, from и to (даты в формате 20060102, to включительно), limit.
+func (s *Server) getAuditHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	if query.Get("task_id") != "" {
+		taskID, err := parseIDParam(r, "task_id")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.TaskID = taskID
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.From, filter.To = from, to
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "параметр limit имеет неверный формат")
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := s.store.ListAuditEntries(filter)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.AuditList{Entries: entries})
+}
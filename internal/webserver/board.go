@@ -0,0 +1,149 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+const (
+	maxColumnTitleLength = 64
+)
+
+// boardErrorStatus возвращает код ответа для ошибки доски
+func boardErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrStatusNotAllowed):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// getBoardHandler возвращает задачи проекта project, разложенные по колонкам статусов.
+// Без параметра project возвращается доска задач, не относящихся к проектам.
+func (s *Server) getBoardHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := ""
+	if r.URL.Query().Get("project") != "" {
+		id, err := parseIDParam(r, "project")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		projectID = id
+	}
+
+	board, err := s.store.Board(projectID)
+	if err != nil {
+		writeJSONError(w, boardErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, board)
+}
+
+// moveTaskHandler переносит задачу id в колонку статуса {"status": "...", "position": N} и возвращает задачу
+func (s *Server) moveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	var move models.TaskMove
+
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := statusValidate(move.Status, false); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currentTask := s.taskSnapshot(id)
+
+	if err := s.store.MoveTask(id, move); err != nil {
+		writeJSONError(w, boardErrorStatus(err), err.Error())
+		return
+	}
+
+	s.recordAudit(r, models.AuditActionUpdate, id, currentTask, s.taskSnapshot(id))
+
+	s.writeTask(w, id)
+}
+
+// getColumnsHandler возвращает колонки доски проекта
+func (s *Server) getColumnsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	columns, err := s.store.ListColumns(id)
+	if err != nil {
+		writeJSONError(w, boardErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ProjectColumnsList{Columns: columns})
+}
+
+// putColumnsHandler задаёт колонки доски проекта {"columns": [{"status": "...", "title": "..."}]}.
+// Пустое название заменяется названием по умолчанию, пустой список возвращает колонки по умолчанию.
+func (s *Server) putColumnsHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectColumnsList
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Columns))
+	for i := range req.Columns {
+		column := &req.Columns[i]
+		column.Title = strings.TrimSpace(column.Title)
+		if err := statusValidate(column.Status, false); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if seen[column.Status] {
+			writeJSONError(w, http.StatusBadRequest, "колонка статуса "+column.Status+" указана дважды")
+			return
+		}
+		seen[column.Status] = true
+		if len([]rune(column.Title)) > maxColumnTitleLength {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("название колонки длиннее %d символов", maxColumnTitleLength))
+			return
+		}
+	}
+
+	if err := s.store.SetColumns(id, req.Columns); err != nil {
+		writeJSONError(w, boardErrorStatus(err), err.Error())
+		return
+	}
+
+	columns, err := s.store.ListColumns(id)
+	if err != nil {
+		writeJSONError(w, boardErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ProjectColumnsList{Columns: columns})
+}
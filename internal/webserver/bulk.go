@@ -0,0 +1,144 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+const (
+	maxBulkOperations = 200
+)
+
+// bulkOperationValidate проверяет поля операции пакетной обработки и нормализует метку
+func bulkOperationValidate(op *models.BulkOperation) error {
+	if _, err := strconv.Atoi(op.ID); err != nil {
+		return errors.New("некорректный формат поля ID")
+	}
+
+	switch op.Op {
+	case models.BulkOpDone, models.BulkOpDelete:
+		return nil
+	case models.BulkOpMove:
+		if (op.Date == "") == (op.By == "") {
+			return errors.New("для переноса нужно указать либо Date, либо By")
+		}
+		if op.Date != "" {
+			if _, err := time.Parse(dateTimeFormat, op.Date); err != nil {
+				return errors.New("поле Date имеет неверный формат")
+			}
+		}
+		return nil
+	case models.BulkOpTag:
+		tags, err := utils.NormalizeTags([]string{op.Tag})
+		if err != nil {
+			return err
+		}
+		if len(tags) != 1 {
+			return errors.New("поле Tag должно быть заполнено")
+		}
+		op.Tag = tags[0]
+		return nil
+	}
+
+	return fmt.Errorf("неизвестная операция %q", op.Op)
+}
+
+// bulkAuditAction возвращает действие журнала изменений для выполненной операции пакета
+func bulkAuditAction(op models.BulkOperation) string {
+	switch op.Op {
+	case models.BulkOpDone:
+		return models.AuditActionDone
+	case models.BulkOpDelete:
+		return models.AuditActionDelete
+	case models.BulkOpMove:
+		if op.By != "" {
+			return models.AuditActionSnooze
+		}
+	}
+	return models.AuditActionUpdate
+}
+
+// bulkTasksHandler выполняет пакет операций над задачами в одной транзакции.
+// В режиме all-or-nothing при ошибке любой операции не применяется ни одна и возвращается 409,
+// в режиме best-effort применяются все выполнимые операции. Результат каждой операции возвращается в results.
+func (s *Server) bulkTasksHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkRequest
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = models.BulkModeAtomic
+	}
+	if req.Mode != models.BulkModeAtomic && req.Mode != models.BulkModeBestEffort {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("поле Mode должно быть %s или %s",
+			models.BulkModeAtomic, models.BulkModeBestEffort))
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBulkOperations {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("в пакете должно быть от 1 до %d операций", maxBulkOperations))
+		return
+	}
+	atomic := req.Mode == models.BulkModeAtomic
+
+	resp := models.BulkResponse{Mode: req.Mode, Results: make([]models.BulkResult, len(req.Operations))}
+	valid := []int{}
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		resp.Results[i] = models.BulkResult{Index: i, Op: op.Op, ID: op.ID}
+		if err := bulkOperationValidate(op); err != nil {
+			resp.Results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	if atomic && len(valid) < len(req.Operations) {
+		for _, i := range valid {
+			resp.Results[i].Error = dbutils.ErrBulkRolledBack.Error()
+		}
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+
+	ops := make([]models.BulkOperation, len(valid))
+	for j, i := range valid {
+		ops[j] = req.Operations[i]
+	}
+
+	now, _ := time.Parse(dateTimeFormat, time.Now().Format(dateTimeFormat))
+	outcomes, err := s.store.ApplyBulk(ops, now, atomic)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for j, i := range valid {
+		if outcomes[j].Err != nil {
+			resp.Results[i].Error = outcomes[j].Err.Error()
+			continue
+		}
+		resp.Results[i].OK = true
+		resp.Applied++
+
+		// Состояния задачи до и после прочитаны в транзакции пакета: у каждой операции над задачей
+		// в журнале своё изменение, даже если в пакете несколько операций над ней
+		s.recordAudit(r, bulkAuditAction(ops[j]), ops[j].ID, outcomes[j].Before, outcomes[j].After)
+	}
+
+	if atomic && resp.Applied < len(req.Operations) {
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
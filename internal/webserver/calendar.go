@@ -0,0 +1,224 @@
+package webserverutils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// Виды записей в календарной ленте
+const (
+	calendarTypeEvent = "event" // Событие на весь день (VEVENT), отображается в календаре
+	calendarTypeTodo  = "todo"  // Задача (VTODO), отображается в списке задач календаря
+)
+
+const (
+	calendarFeedPath   = "/api/calendar.ics"
+	calendarTokenBytes = 32
+	calendarUIDDomain  = "webtasksplanner"
+	icalTimestamp      = "20060102T150405Z"
+)
+
+// Приоритеты iCalendar для приоритетов задачи 1-4: в iCalendar 1 - наивысший, 9 - наименьший
+var calendarPriorities = map[int]int{1: 1, 2: 3, 3: 5, 4: 7}
+
+// Статусы VTODO для статусов задачи на доске
+var calendarTodoStatuses = map[string]string{
+	models.TaskStatusInProgress: "IN-PROCESS",
+	models.TaskStatusDone:       "COMPLETED",
+}
+
+// calendarTokenHash возвращает хеш токена, под которым токен хранится в БД
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// postCalendarTokenHandler выпускает токен календарной подписки пользователю из заголовка X-User.
+// Прежний токен пользователя перестаёт действовать. Токен возвращается только в этом ответе.
+func (s *Server) postCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSpace(r.Header.Get(actorHeader)) == "" {
+		writeJSONError(w, http.StatusBadRequest, "пользователь должен быть указан в заголовке "+actorHeader)
+		return
+	}
+
+	raw := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.store.SetCalendarToken(actorFromRequest(r), calendarTokenHash(token)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.CalendarToken{Token: token, URL: calendarFeedPath + "?token=" + token})
+}
+
+// deleteCalendarTokenHandler отзывает токен календарной подписки пользователя из заголовка X-User
+func (s *Server) deleteCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.DeleteCalendarToken(actorFromRequest(r)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, dbutils.ErrCalendarTokenNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// calendarComponent возвращает запись iCalendar для задачи: VEVENT на весь день даты задачи
+// или VTODO с датой задачи в качестве срока. Повторяющаяся задача получает RRULE по правилу repeat.
+func calendarComponent(task models.FullTask, kind string, stamp string) (string, error) {
+	date, err := time.Parse(dateTimeFormat, task.Date)
+	if err != nil {
+		return "", fmt.Errorf("задача %s: %w", task.ID, err)
+	}
+	rrule, err := utils.RepeatRRule(task.Repeat)
+	if err != nil {
+		return "", fmt.Errorf("задача %s: %w", task.ID, err)
+	}
+
+	name := "VEVENT"
+	if kind == calendarTypeTodo {
+		name = "VTODO"
+	}
+
+	lines := []string{
+		"BEGIN:" + name,
+		"UID:task-" + task.ID + "@" + calendarUIDDomain,
+		"DTSTAMP:" + stamp,
+	}
+	if created, err := time.Parse(time.RFC3339, task.CreatedAt); err == nil {
+		lines = append(lines, "CREATED:"+created.UTC().Format(icalTimestamp))
+	}
+	lines = append(lines, "DTSTART;VALUE=DATE:"+task.Date)
+	if kind == calendarTypeTodo {
+		lines = append(lines, "DUE;VALUE=DATE:"+task.Date)
+	} else {
+		lines = append(lines, "DTEND;VALUE=DATE:"+date.AddDate(0, 0, 1).Format(dateTimeFormat))
+	}
+	if rrule != "" {
+		lines = append(lines, "RRULE:"+rrule)
+	}
+	lines = append(lines, "SUMMARY:"+utils.ICalText(task.Title))
+	if task.Comment != "" {
+		lines = append(lines, "DESCRIPTION:"+utils.ICalText(task.Comment))
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			categories[i] = utils.ICalText(tag)
+		}
+		lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	if task.Priority != nil && calendarPriorities[*task.Priority] != 0 {
+		lines = append(lines, fmt.Sprintf("PRIORITY:%d", calendarPriorities[*task.Priority]))
+	}
+	if kind == calendarTypeTodo {
+		status, ok := calendarTodoStatuses[task.Status]
+		if !ok {
+			status = "NEEDS-ACTION"
+		}
+		lines = append(lines, "STATUS:"+status)
+	}
+	lines = append(lines, "END:"+name)
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(utils.ICalLine(line))
+	}
+	return b.String(), nil
+}
+
+// getCalendarHandler отдаёт ленту iCalendar (RFC 5545) с активными задачами для подписки в календаре.
+// Доступ - по секретному токену пользователя в параметре token. type - event (по умолчанию) или todo.
+func (s *Server) getCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	token := query.Get("token")
+	if token == "" {
+		writeJSONError(w, http.StatusUnauthorized, "не указан токен календаря")
+		return
+	}
+	actor, err := s.store.CalendarTokenActor(calendarTokenHash(token))
+	if errors.Is(err, dbutils.ErrCalendarTokenNotFound) {
+		writeJSONError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	kind := query.Get("type")
+	if kind == "" {
+		kind = calendarTypeEvent
+	}
+	if kind != calendarTypeEvent && kind != calendarTypeTodo {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("параметр type должен быть %s или %s",
+			calendarTypeEvent, calendarTypeTodo))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	out := &exportWriter{ResponseWriter: w}
+	stamp := time.Now().UTC().Format(icalTimestamp)
+
+	write := func(lines ...string) error {
+		for _, line := range lines {
+			if _, err := out.Write([]byte(utils.ICalLine(line))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = write(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//"+calendarUIDDomain+"//RU",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:"+utils.ICalText("Задачи ("+actor+")"),
+	)
+	if err == nil {
+		err = s.store.ExportTasks(func(task models.ExportTask) error {
+			if task.DeletedAt != "" {
+				return nil
+			}
+			component, err := calendarComponent(task.FullTask, kind, stamp)
+			if err != nil {
+				// Задача с некорректной датой или правилом не должна ломать всю ленту
+				log.Printf("Задача пропущена в календаре: %v", err)
+				return nil
+			}
+			_, err = out.Write([]byte(component))
+			return err
+		})
+	}
+	if err == nil {
+		err = write("END:VCALENDAR")
+	}
+
+	if err != nil {
+		if !out.started {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Printf("Ошибка выгрузки календаря: %v", err)
+	}
+}
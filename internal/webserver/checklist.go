@@ -0,0 +1,150 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+const (
+	maxChecklistTitleLength = 256
+)
+
+// checklistErrorStatus возвращает код ответа для ошибки хранилища чек-листов
+func checklistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrChecklistItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrChecklistMismatch):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// getChecklistHandler возвращает чек-лист задачи task_id
+func (s *Server) getChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := s.store.ListChecklist(taskID)
+	if err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ChecklistList{Items: items})
+}
+
+// postChecklistItemHandler добавляет пункт {"title": "..."} в конец чек-листа задачи task_id
+func (s *Server) postChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	var item models.ChecklistItem
+
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		writeJSONError(w, http.StatusBadRequest, "поле Title должно быть заполнено")
+		return
+	}
+	if len([]rune(item.Title)) > maxChecklistTitleLength {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("пункт чек-листа длиннее %d символов", maxChecklistTitleLength))
+		return
+	}
+
+	id, err := s.store.AddChecklistItem(taskID, item.Title)
+	if err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.HTTPJSONResponseID{ID: id})
+}
+
+// toggleChecklistItemHandler меняет отметку о выполнении пункта id и возвращает пункт
+func (s *Server) toggleChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item, err := s.store.ToggleChecklistItem(id)
+	if err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// reorderChecklistHandler расставляет пункты чек-листа задачи task_id в порядке {"ids": [...]}
+func (s *Server) reorderChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []json.Number `json:"ids"`
+	}
+
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ids := make([]string, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		ids = append(ids, id.String())
+	}
+
+	if err := s.store.ReorderChecklist(taskID, ids); err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	items, err := s.store.ListChecklist(taskID)
+	if err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ChecklistList{Items: items})
+}
+
+// deleteChecklistItemHandler удаляет пункт чек-листа id
+func (s *Server) deleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.DeleteChecklistItem(id); err != nil {
+		writeJSONError(w, checklistErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,155 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+const (
+	maxCommentTextLength = 4000
+)
+
+var errNotCommentAuthor = errors.New("изменять и удалять комментарий может только его автор")
+
+// commentErrorStatus возвращает код ответа для ошибки хранилища обсуждений
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errNotCommentAuthor):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// decodeCommentText читает из тела запроса {"text": "..."} и проверяет текст комментария
+func decodeCommentText(r *http.Request) (string, error) {
+	var comment models.TaskComment
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSpace(comment.Text)
+	if text == "" {
+		return "", errors.New("поле Text должно быть заполнено")
+	}
+	if len([]rune(text)) > maxCommentTextLength {
+		return "", fmt.Errorf("комментарий длиннее %d символов", maxCommentTextLength)
+	}
+	return text, nil
+}
+
+// checkCommentAuthor проверяет, что запрос выполняет автор комментария id
+func (s *Server) checkCommentAuthor(r *http.Request, id string) error {
+	comment, err := s.store.GetComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.Author != actorFromRequest(r) {
+		return errNotCommentAuthor
+	}
+	return nil
+}
+
+// getCommentsHandler возвращает обсуждение задачи task_id
+func (s *Server) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comments, err := s.store.ListComments(taskID)
+	if err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TaskCommentsList{Comments: comments})
+}
+
+// postCommentHandler добавляет комментарий к задаче task_id от имени пользователя из заголовка X-User
+func (s *Server) postCommentHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "task_id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	text, err := decodeCommentText(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.store.AddComment(models.TaskComment{TaskID: taskID, Author: actorFromRequest(r), Text: text})
+	if err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.HTTPJSONResponseID{ID: id})
+}
+
+// putCommentHandler заменяет текст комментария id и возвращает комментарий
+func (s *Server) putCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	text, err := decodeCommentText(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.checkCommentAuthor(r, id); err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	if err := s.store.UpdateComment(id, text); err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	comment, err := s.store.GetComment(id)
+	if err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, comment)
+}
+
+// deleteCommentHandler удаляет комментарий id
+func (s *Server) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.checkCommentAuthor(r, id); err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	if err := s.store.DeleteComment(id); err != nil {
+		writeJSONError(w, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
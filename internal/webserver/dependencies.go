@@ -0,0 +1,80 @@
+package webserverutils
+
+import (
+	"errors"
+	"net/http"
+
+	dbutils "webtasksplannerexample/internal/db"
+)
+
+// dependencyErrorStatus возвращает код ответа для ошибки хранилища зависимостей
+func dependencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrDependencyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrDependencyCycle):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseDependencyParams возвращает идентификаторы задачи id и блокирующей её задачи blocker_id
+func parseDependencyParams(r *http.Request) (string, string, error) {
+	taskID, err := parseIDParam(r, "id")
+	if err != nil {
+		return "", "", err
+	}
+	blockerID, err := parseIDParam(r, "blocker_id")
+	if err != nil {
+		return "", "", err
+	}
+	return taskID, blockerID, nil
+}
+
+// addDependencyHandler отмечает, что задача id заблокирована задачей blocker_id, и возвращает задачу
+func (s *Server) addDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, blockerID, err := parseDependencyParams(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.AddDependency(taskID, blockerID); err != nil {
+		writeJSONError(w, dependencyErrorStatus(err), err.Error())
+		return
+	}
+
+	s.writeTask(w, taskID)
+}
+
+// removeDependencyHandler снимает блокировку задачи id задачей blocker_id и возвращает задачу
+func (s *Server) removeDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, blockerID, err := parseDependencyParams(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.RemoveDependency(taskID, blockerID); err != nil {
+		writeJSONError(w, dependencyErrorStatus(err), err.Error())
+		return
+	}
+
+	s.writeTask(w, taskID)
+}
+
+// writeTask отправляет текущее состояние задачи id
+func (s *Server) writeTask(w http.ResponseWriter, id string) {
+	task, err := s.store.Get(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, dbutils.ErrTaskNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
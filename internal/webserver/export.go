@@ -0,0 +1,164 @@
+package webserverutils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	models "webtasksplannerexample/internal/models"
+)
+
+// Форматы выгрузки задач
+const (
+	exportFormatCSV    = "csv"
+	exportFormatJSON   = "json"
+	exportFormatNDJSON = "ndjson"
+)
+
+// exportCSVColumns - столбцы выгрузки в CSV. Порядок и названия входят в формат выгрузки:
+// новые столбцы добавляются только в конец.
+var exportCSVColumns = []string{
+	"id", "date", "title", "comment", "repeat", "tags", "project_id", "priority",
+	"status", "estimate", "created_at", "deleted_at", "history",
+}
+
+// exportCSVRecord возвращает строку CSV для задачи: метки через пробел, история - JSON-массивом,
+// незаданные проект, приоритет и оценка - пустыми значениями
+func exportCSVRecord(task models.ExportTask) ([]string, error) {
+	optional := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+
+	projectID := ""
+	if task.ProjectID != nil {
+		projectID = *task.ProjectID
+	}
+
+	history := ""
+	if len(task.History) > 0 {
+		data, err := json.Marshal(task.History)
+		if err != nil {
+			return nil, err
+		}
+		history = string(data)
+	}
+
+	return []string{
+		task.ID, task.Date, task.Title, task.Comment, task.Repeat, strings.Join(task.Tags, " "), projectID,
+		optional(task.Priority), task.Status, optional(task.Estimate), task.CreatedAt, task.DeletedAt, history,
+	}, nil
+}
+
+// exportWriter отслеживает, началась ли уже отправка выгрузки: после этого сообщить об ошибке кодом ответа нельзя
+type exportWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+// getExportHandler выгружает все задачи, включая находящиеся в корзине, с метками и историей изменений
+// в формате format: csv, json (по умолчанию, {"tasks": [...]}) или ndjson (задача на строку).
+// Задачи передаются по мере чтения из хранилища, без ограничения количества.
+func (s *Server) getExportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatJSON
+	}
+
+	var contentType string
+	switch format {
+	case exportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case exportFormatJSON:
+		contentType = "application/json; charset=utf-8"
+	case exportFormatNDJSON:
+		contentType = "application/x-ndjson; charset=utf-8"
+	default:
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("параметр format должен быть %s, %s или %s",
+			exportFormatCSV, exportFormatJSON, exportFormatNDJSON))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="tasks-%s.%s"`, time.Now().Format(dateTimeFormat), format))
+	out := &exportWriter{ResponseWriter: w}
+
+	var err error
+	switch format {
+	case exportFormatCSV:
+		err = s.exportCSV(out)
+	default:
+		err = s.exportJSON(out, format == exportFormatNDJSON)
+	}
+	if err != nil {
+		if !out.started {
+			w.Header().Del("Content-Disposition")
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Printf("Ошибка выгрузки задач: %v", err)
+	}
+}
+
+func (s *Server) exportCSV(w *exportWriter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVColumns); err != nil {
+		return err
+	}
+
+	err := s.store.ExportTasks(func(task models.ExportTask) error {
+		record, err := exportCSVRecord(task)
+		if err != nil {
+			return err
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *Server) exportJSON(w *exportWriter, ndjson bool) error {
+	encoder := json.NewEncoder(w)
+	first := true
+
+	err := s.store.ExportTasks(func(task models.ExportTask) error {
+		if !ndjson {
+			separator := ","
+			if first {
+				separator = `{"tasks":[`
+			}
+			if _, err := w.Write([]byte(separator)); err != nil {
+				return err
+			}
+		}
+		first = false
+		return encoder.Encode(task)
+	})
+	if err != nil || ndjson {
+		return err
+	}
+
+	closing := "]}\n"
+	if first {
+		closing = `{"tasks":[]}` + "\n"
+	}
+	_, err = w.Write([]byte(closing))
+	return err
+}
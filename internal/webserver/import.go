@@ -0,0 +1,347 @@
+package webserverutils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+const (
+	importFormatTodoist = "todoist" // CSV-выгрузка Todoist
+	importMaxSize       = 10 << 20  // Максимальный размер импортируемого файла, байт
+	importMaxRows       = 5000
+)
+
+// importRow - задача, прочитанная из строки импортируемого файла
+type importRow struct {
+	task models.Task
+	skip string // Причина пропуска строки
+	err  error  // Ошибка разбора строки
+}
+
+// errImportTooManyRows возвращается, если в файле больше importMaxRows строк
+var errImportTooManyRows = fmt.Errorf("в файле больше %d строк", importMaxRows)
+
+// importJSONRow разбирает задачу в формате выгрузки json или ndjson
+func importJSONRow(data []byte) importRow {
+	var task models.FullTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return importRow{err: err}
+	}
+	if task.DeletedAt != "" {
+		return importRow{skip: "задача находится в корзине"}
+	}
+	return importRow{task: task.Task}
+}
+
+// importJSON читает задачи из выгрузки json ({"tasks": [...]}) или из JSON-массива задач
+func importJSON(body []byte) ([]importRow, error) {
+	var items []json.RawMessage
+
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, err
+		}
+	} else {
+		var list struct {
+			Tasks []json.RawMessage `json:"tasks"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, err
+		}
+		items = list.Tasks
+	}
+	if len(items) > importMaxRows {
+		return nil, errImportTooManyRows
+	}
+
+	rows := make([]importRow, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, importJSONRow(item))
+	}
+	return rows, nil
+}
+
+// importNDJSON читает задачи из выгрузки ndjson, пустые строки пропускаются
+func importNDJSON(body []byte) ([]importRow, error) {
+	var rows []importRow
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, importMaxSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(rows) == importMaxRows {
+			return nil, errImportTooManyRows
+		}
+		rows = append(rows, importJSONRow(line))
+	}
+	return rows, scanner.Err()
+}
+
+// importCSVRecords читает CSV с заголовком и передаёт в fn каждую строку как отображение "столбец - значение".
+// Ошибка разбора строки передаётся в fn вместо значений, остальные строки продолжают читаться.
+func importCSVRecords(body []byte, required []string, fn func(fields map[string]string, err error)) error {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать заголовок CSV: %w", err)
+	}
+	for _, column := range required {
+		found := false
+		for _, name := range header {
+			found = found || strings.TrimSpace(name) == column
+		}
+		if !found {
+			return fmt.Errorf("в заголовке CSV нет столбца %s", column)
+		}
+	}
+
+	for count := 0; ; count++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if count == importMaxRows {
+			return errImportTooManyRows
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(nil, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				fields[strings.TrimSpace(name)] = record[i]
+			}
+		}
+		fn(fields, nil)
+	}
+}
+
+// importOptionalInt разбирает необязательное целое значение столбца column, пустое значение - не задано
+func importOptionalInt(value, column string) (*int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("столбец %s должен быть целым числом", column)
+	}
+	return &n, nil
+}
+
+// importCSV читает задачи из выгрузки csv. Столбцы сопоставляются по заголовку, обязателен только title,
+// неизвестные столбцы и столбцы только для чтения (id, created_at, history) не учитываются
+func importCSV(body []byte) ([]importRow, error) {
+	var rows []importRow
+
+	err := importCSVRecords(body, []string{"title"}, func(fields map[string]string, err error) {
+		if err != nil {
+			rows = append(rows, importRow{err: err})
+			return
+		}
+		if fields["deleted_at"] != "" {
+			rows = append(rows, importRow{skip: "задача находится в корзине"})
+			return
+		}
+
+		task := models.Task{
+			Date:    fields["date"],
+			Title:   fields["title"],
+			Comment: fields["comment"],
+			Repeat:  fields["repeat"],
+			Tags:    strings.Fields(fields["tags"]),
+			Status:  fields["status"],
+		}
+		if projectID := strings.TrimSpace(fields["project_id"]); projectID != "" {
+			task.ProjectID = &projectID
+		}
+		if task.Priority, err = importOptionalInt(fields["priority"], "priority"); err == nil {
+			task.Estimate, err = importOptionalInt(fields["estimate"], "estimate")
+		}
+		rows = append(rows, importRow{task: task, err: err})
+	})
+	return rows, err
+}
+
+// importTodoist читает задачи из CSV-выгрузки Todoist (столбцы TYPE, CONTENT, DESCRIPTION, PRIORITY, DATE,
+// DURATION, DURATION_UNIT). Строки, отличные от задач (разделы, заметки), пропускаются. Метки @метка из CONTENT
+// становятся метками задачи, DESCRIPTION - комментарием, DURATION - оценкой. Приоритеты Todoist p1-p3
+// соответствуют приоритетам 1-3, p4 (обычный) - задаче без приоритета.
+func importTodoist(body []byte, now time.Time) ([]importRow, error) {
+	var rows []importRow
+
+	err := importCSVRecords(body, []string{"TYPE", "CONTENT"}, func(fields map[string]string, err error) {
+		if err != nil {
+			rows = append(rows, importRow{err: err})
+			return
+		}
+		if kind := strings.ToLower(strings.TrimSpace(fields["TYPE"])); kind != "task" {
+			rows = append(rows, importRow{skip: fmt.Sprintf("строка Todoist типа %q не является задачей", kind)})
+			return
+		}
+
+		var task models.Task
+		task.Title, task.Tags = utils.ParseTodoistContent(fields["CONTENT"])
+		task.Comment = strings.TrimSpace(fields["DESCRIPTION"])
+
+		row := importRow{}
+		if task.Date, task.Repeat, err = utils.ParseTodoistDate(now, fields["DATE"]); err != nil {
+			row.err = err
+		}
+
+		priority, err := importOptionalInt(fields["PRIORITY"], "PRIORITY")
+		switch {
+		case err != nil:
+			row.err = errors.Join(row.err, err)
+		case priority != nil && *priority >= 1 && *priority <= 3:
+			task.Priority = priority
+		case priority != nil && *priority != 4:
+			row.err = errors.Join(row.err, errors.New("приоритет Todoist должен быть от 1 до 4"))
+		}
+
+		duration, err := importOptionalInt(fields["DURATION"], "DURATION")
+		switch {
+		case err != nil:
+			row.err = errors.Join(row.err, err)
+		case duration == nil:
+		case strings.EqualFold(fields["DURATION_UNIT"], "day"):
+			minutes := *duration * 24 * 60
+			task.Estimate = &minutes
+		default:
+			task.Estimate = duration
+		}
+
+		row.task = task
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+// importProjectCheck проверяет, что в проект задачи можно добавить задачу. Проверка выполняется до сохранения,
+// чтобы в режиме dry_run сообщалось о тех же ошибках, что и при импорте.
+func (s *Server) importProjectCheck(task models.Task) error {
+	if task.ProjectID == nil || *task.ProjectID == "" {
+		return nil
+	}
+	project, err := s.store.GetProject(*task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return dbutils.ErrProjectArchived
+	}
+	return nil
+}
+
+// importHandler создаёт задачи из файла в теле запроса. format - json (по умолчанию), ndjson или csv
+// (формат выгрузки GET /api/export) либо todoist (CSV-выгрузка Todoist). Каждая строка проверяется
+// по тем же правилам, что и в POST /api/task; ошибка в строке не мешает импорту остальных.
+// С dry_run=true задачи только проверяются: в ответе видно, какие задачи были бы созданы.
+func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = exportFormatJSON
+	}
+
+	dryRun := false
+	if value := query.Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "параметр dry_run должен быть true или false")
+			return
+		}
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, importMaxSize))
+	if err != nil {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("размер файла больше %d байт", importMaxSize))
+		return
+	}
+
+	now := time.Now()
+	var rows []importRow
+	switch format {
+	case exportFormatJSON:
+		rows, err = importJSON(body)
+	case exportFormatNDJSON:
+		rows, err = importNDJSON(body)
+	case exportFormatCSV:
+		rows, err = importCSV(body)
+	case importFormatTodoist:
+		rows, err = importTodoist(body, now)
+	default:
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("параметр format должен быть %s, %s, %s или %s",
+			exportFormatJSON, exportFormatNDJSON, exportFormatCSV, importFormatTodoist))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := models.ImportResponse{Format: format, DryRun: dryRun, Rows: make([]models.ImportResult, 0, len(rows))}
+	for i, row := range rows {
+		result := models.ImportResult{Row: i + 1}
+
+		switch {
+		case row.skip != "":
+			result.Skipped, result.Error = true, row.skip
+			resp.Skipped++
+		case row.err != nil:
+			result.Error = row.err.Error()
+			resp.Failed++
+		default:
+			task, err := NormalizeTask(row.task, now)
+			if err == nil {
+				err = s.importProjectCheck(task)
+			}
+			if err == nil && !dryRun {
+				var id int64
+				if id, err = s.addTask(r, task); err == nil {
+					result.ID = strconv.FormatInt(id, 10)
+					resp.Created++
+				}
+			}
+			if err != nil {
+				result.Error = err.Error()
+				resp.Failed++
+				break
+			}
+			result.OK, result.Task = true, &task
+			resp.Valid++
+		}
+
+		resp.Rows = append(resp.Rows, result)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
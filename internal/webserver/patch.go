@@ -0,0 +1,142 @@
+package webserverutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// mergePatch применяет к документу target изменения patch по правилам JSON Merge Patch (RFC 7386):
+// null удаляет поле, вложенные объекты объединяются, остальные значения заменяются целиком
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// taskErrorStatus возвращает код ответа для ошибки изменения задачи
+func taskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrProjectArchived):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// patchTaskHandler изменяет задачу id только в переданных полях (JSON Merge Patch) и возвращает задачу.
+// null возвращает поле к значению по умолчанию: убирает метки, проект, приоритет, оценку, статус становится todo.
+// Если меняется правило повторения, а дата не передана, задача переносится на первую дату по новому правилу.
+// Дата, которая не меняется патчем, сохраняется, даже если она уже прошла.
+func (s *Server) patchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var patch map[string]any
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		writeJSONError(w, http.StatusBadRequest, "тело запроса должно быть JSON-объектом")
+		return
+	}
+
+	currentTask, err := s.store.Get(id)
+	if err != nil {
+		writeJSONError(w, taskErrorStatus(err), err.Error())
+		return
+	}
+
+	// Изменения применяются к редактируемым полям задачи, остальные поля FullTask только для чтения
+	var document any
+	data, _ := json.Marshal(currentTask.Task)
+	if err := json.Unmarshal(data, &document); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	data, _ = json.Marshal(mergePatch(document, patch))
+
+	var task models.Task
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&task); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// В Update отсутствующее поле означает "не менять", поэтому удалённые патчем поля задаются явно
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+	if task.ProjectID == nil {
+		task.ProjectID = new(string)
+	}
+	if task.Priority == nil {
+		task.Priority = new(int)
+	}
+	if task.Estimate == nil {
+		task.Estimate = new(int)
+	}
+	if task.Status == "" {
+		task.Status = models.TaskStatusTodo
+	}
+
+	if _, dateSet := patch["date"]; !dateSet && task.Repeat != "" && task.Repeat != currentTask.Repeat &&
+		utils.IsValidFormat(task.Repeat, utils.RepeatValidFormats) {
+		if task.Date, err = utils.FirstDate(time.Now(), task.Date, task.Repeat); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	fullTask := models.FullTask{ID: id, Task: task}
+	if err := TaskValidate(fullTask); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Дата нормализуется, только если патч меняет дату или правило повторения:
+	// правка других полей просроченной задачи не должна переносить её на сегодня
+	_, dateSet := patch["date"]
+	_, repeatSet := patch["repeat"]
+	if dateSet || repeatSet {
+		fullTask.Task, err = NormalizeTask(fullTask.Task, time.Now())
+	} else {
+		fullTask.Task, err = normalizeTaskFields(fullTask.Task)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.Update(fullTask); err != nil {
+		writeJSONError(w, taskErrorStatus(err), err.Error())
+		return
+	}
+
+	s.recordAudit(r, models.AuditActionUpdate, id, &currentTask, s.taskSnapshot(id))
+
+	s.writeTask(w, id)
+}
@@ -0,0 +1,144 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+const (
+	maxProjectNameLength = 64
+)
+
+var projectColorRegexp = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// projectNormalize убирает лишние пробелы в названии, приводит цвет к нижнему регистру и проверяет поля проекта
+func projectNormalize(p *models.Project) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Color = strings.ToLower(strings.TrimSpace(p.Color))
+
+	if p.Name == "" {
+		return errors.New("поле Name должно быть заполнено")
+	}
+	if len([]rune(p.Name)) > maxProjectNameLength {
+		return errors.New("название проекта длиннее " + strconv.Itoa(maxProjectNameLength) + " символов")
+	}
+	if p.Color != "" && !projectColorRegexp.MatchString(p.Color) {
+		return errors.New("поле Color должно иметь формат #rrggbb")
+	}
+	return nil
+}
+
+// projectErrorStatus возвращает код ответа для ошибки хранилища проектов
+func projectErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrProjectArchived):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// getProjectsHandler возвращает список проектов, архивные - при archived=true
+func (s *Server) getProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("archived"))
+
+	projects, err := s.store.ListProjects(includeArchived)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ProjectsList{Projects: projects})
+}
+
+func (s *Server) postProjectHandler(w http.ResponseWriter, r *http.Request) {
+	var project models.Project
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := projectNormalize(&project); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.store.AddProject(project)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.HTTPJSONResponseID{ID: id})
+}
+
+func (s *Server) getProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project, err := s.store.GetProject(id)
+	if err != nil {
+		writeJSONError(w, projectErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, project)
+}
+
+// putProjectHandler перезаписывает название, цвет и признак архива проекта
+func (s *Server) putProjectHandler(w http.ResponseWriter, r *http.Request) {
+	var project models.Project
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	project.ID = id
+	if err := projectNormalize(&project); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.UpdateProject(project); err != nil {
+		writeJSONError(w, projectErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, project)
+}
+
+// deleteProjectHandler удаляет проект, его задачи остаются без проекта
+func (s *Server) deleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.DeleteProject(id); err != nil {
+		writeJSONError(w, projectErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
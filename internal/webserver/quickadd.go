@@ -0,0 +1,60 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// quickTaskHandler создаёт задачу из одной строки {"text": "..."}: дата, правило повторения,
+// метки и приоритет извлекаются из текста, остальное становится заголовком
+func (s *Server) quickTaskHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	parsed := utils.ParseQuickTask(now, req.Text)
+
+	task := models.Task{
+		Title:  parsed.Title,
+		Date:   parsed.Date,
+		Repeat: parsed.Repeat,
+		Tags:   parsed.Tags,
+	}
+	if parsed.Priority != 0 {
+		task.Priority = &parsed.Priority
+	}
+
+	task, err := NormalizeTask(task, now)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.addTask(r, task)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	unparsed := parsed.Unparsed
+	if unparsed == nil {
+		unparsed = []string{}
+	}
+	writeJSON(w, http.StatusCreated, models.QuickAddResult{
+		ID:       strconv.FormatInt(id, 10),
+		Task:     task,
+		Unparsed: unparsed,
+	})
+}
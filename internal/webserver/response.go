@@ -0,0 +1,54 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	models "webtasksplannerexample/internal/models"
+)
+
+// writeJSON сериализует v в тело ответа с указанным кодом статуса
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	jsonResp, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if _, err := w.Write(jsonResp); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// writeJSONError отправляет ошибку в принятом в API формате {"error": "..."}
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, models.HTTPJSONErrorMessageResponse{Error: msg})
+}
+
+// parseIDParam возвращает идентификатор из параметра запроса с именем name
+func parseIDParam(r *http.Request, name string) (string, error) {
+	idParam := r.URL.Query().Get(name)
+	if idParam == "" {
+		return "", errors.New("не указан идентификатор")
+	}
+	if _, err := strconv.ParseInt(idParam, 10, 64); err != nil {
+		return "", errors.New("неверный формат идентификатора")
+	}
+	return idParam, nil
+}
+
+// parsePathID возвращает идентификатор из параметра маршрута с именем name, например {id}
+func parsePathID(r *http.Request, name string) (string, error) {
+	idParam := chi.URLParam(r, name)
+	if _, err := strconv.ParseInt(idParam, 10, 64); err != nil {
+		return "", errors.New("неверный формат идентификатора")
+	}
+	return idParam, nil
+}
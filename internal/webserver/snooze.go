@@ -0,0 +1,50 @@
+package webserverutils
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// snoozeTaskHandler откладывает задачу id на период by (1d, 3d, 1w или next-monday) и возвращает задачу.
+// У повторяющейся задачи переносится только текущее повторение, правило повторения не меняется.
+func (s *Server) snoozeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currentTask, err := s.store.Get(id)
+	if err != nil {
+		if errors.Is(err, dbutils.ErrTaskNotFound) {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	date, err := utils.SnoozeDate(time.Now(), currentTask.Date, r.URL.Query().Get("by"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.Reschedule(id, date); err != nil {
+		if errors.Is(err, dbutils.ErrTaskNotFound) {
+			writeJSONError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.recordAudit(r, models.AuditActionSnooze, id, &currentTask, s.taskSnapshot(id))
+
+	s.writeTask(w, id)
+}
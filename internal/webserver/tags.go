@@ -0,0 +1,18 @@
+package webserverutils
+
+import (
+	"net/http"
+
+	models "webtasksplannerexample/internal/models"
+)
+
+// getTagsHandler возвращает метки с количеством отмеченных ими задач
+func (s *Server) getTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.store.ListTags()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TagsList{Tags: tags})
+}
@@ -0,0 +1,240 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+const (
+	maxTemplateChecklistItems = 100
+	maxTemplateDateOffset     = 365
+	maxTemplateInstances      = 20
+	maxTemplateCommentLength  = 512 // Как в ограничении поля comment таблицы scheduler
+)
+
+// templateNormalize убирает лишние пробелы, нормализует метки и проверяет поля шаблона
+func templateNormalize(t *models.TaskTemplate) error {
+	t.Title = strings.TrimSpace(t.Title)
+	t.Repeat = strings.TrimSpace(t.Repeat)
+
+	if t.Title == "" {
+		return errors.New("поле Title должно быть заполнено")
+	}
+	if t.Repeat != "" && !utils.IsValidFormat(t.Repeat, utils.RepeatValidFormats) {
+		return errors.New("поле Repeat имеет неверный формат")
+	}
+	// Шаблон с более длинным комментарием сохранился бы, но задачи по нему не создавались бы
+	if len([]rune(t.Comment)) > maxTemplateCommentLength {
+		return fmt.Errorf("поле Comment длиннее %d символов", maxTemplateCommentLength)
+	}
+	if t.DateOffset < 0 || t.DateOffset > maxTemplateDateOffset {
+		return fmt.Errorf("поле DateOffset должно быть от 0 до %d дней", maxTemplateDateOffset)
+	}
+
+	tags, err := utils.NormalizeTags(t.Tags)
+	if err != nil {
+		return err
+	}
+	t.Tags = tags
+
+	if len(t.Checklist) > maxTemplateChecklistItems {
+		return fmt.Errorf("в чек-листе шаблона больше %d пунктов", maxTemplateChecklistItems)
+	}
+	checklist := make([]string, 0, len(t.Checklist))
+	for _, item := range t.Checklist {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return errors.New("пункт чек-листа не может быть пустым")
+		}
+		if len([]rune(item)) > maxChecklistTitleLength {
+			return fmt.Errorf("пункт чек-листа длиннее %d символов", maxChecklistTitleLength)
+		}
+		checklist = append(checklist, item)
+	}
+	t.Checklist = checklist
+
+	return nil
+}
+
+// templateErrorStatus возвращает код ответа для ошибки хранилища шаблонов
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTemplateNotFound), errors.Is(err, dbutils.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrProjectArchived):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *Server) getTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.store.ListTemplates()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TaskTemplatesList{Templates: templates})
+}
+
+func (s *Server) postTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var template models.TaskTemplate
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := templateNormalize(&template); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.store.AddTemplate(template)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.HTTPJSONResponseID{ID: id})
+}
+
+func (s *Server) getTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	template, err := s.store.GetTemplate(id)
+	if err != nil {
+		writeJSONError(w, templateErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+// putTemplateHandler перезаписывает все поля шаблона
+func (s *Server) putTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var template models.TaskTemplate
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	template.ID = id
+	if err := templateNormalize(&template); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.UpdateTemplate(template); err != nil {
+		writeJSONError(w, templateErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (s *Server) deleteTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.store.DeleteTemplate(id); err != nil {
+		writeJSONError(w, templateErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// instantiateTemplateHandler создаёт по шаблону count задач (по умолчанию одну) с датой date + DateOffset
+// и возвращает их идентификаторы. Задачи проходят те же проверки, что и при создании через POST /api/task.
+func (s *Server) instantiateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var params models.TemplateInstantiate
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.Count == 0 {
+		params.Count = 1
+	}
+	if params.Count < 0 || params.Count > maxTemplateInstances {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("поле Count должно быть от 1 до %d", maxTemplateInstances))
+		return
+	}
+
+	now := time.Now()
+	base, _ := time.Parse(dateTimeFormat, now.Format(dateTimeFormat))
+	if params.Date != "" {
+		if base, err = time.Parse(dateTimeFormat, params.Date); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "поле Date имеет неверный формат")
+			return
+		}
+	}
+
+	template, err := s.store.GetTemplate(id)
+	if err != nil {
+		writeJSONError(w, templateErrorStatus(err), err.Error())
+		return
+	}
+
+	task, err := NormalizeTask(models.Task{
+		Date:      base.AddDate(0, 0, template.DateOffset).Format(dateTimeFormat),
+		Title:     template.Title,
+		Comment:   template.Comment,
+		Repeat:    template.Repeat,
+		Tags:      template.Tags,
+		ProjectID: params.ProjectID,
+	}, now)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Задачи вместе с чек-листами создаются одной транзакцией, поэтому при ошибке не остаётся частично созданных задач
+	created, err := s.store.InstantiateTemplate(task, template.Checklist, params.Count)
+	if err != nil {
+		writeJSONError(w, templateErrorStatus(err), err.Error())
+		return
+	}
+
+	ids := make([]string, 0, len(created))
+	for _, taskID := range created {
+		id := strconv.FormatInt(taskID, 10)
+		s.recordAudit(r, models.AuditActionCreate, id, nil, s.taskSnapshot(id))
+		ids = append(ids, id)
+	}
+
+	writeJSON(w, http.StatusCreated, models.TemplateTasks{IDs: ids})
+}
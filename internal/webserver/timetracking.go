@@ -0,0 +1,164 @@
+package webserverutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+const (
+	// Вручную за один раз можно учесть не больше суток
+	maxManualEntryMinutes = 24 * 60
+)
+
+// timeErrorStatus возвращает код ответа для ошибки учёта времени
+func timeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dbutils.ErrTaskNotFound), errors.Is(err, dbutils.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dbutils.ErrTimerRunning), errors.Is(err, dbutils.ErrTimerNotRunning):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// startTimerHandler запускает таймер пользователя из заголовка X-User по задаче id
+func (s *Server) startTimerHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.store.StartTimer(taskID, actorFromRequest(r), time.Now())
+	if err != nil {
+		writeJSONError(w, timeErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.HTTPJSONResponseID{ID: id})
+}
+
+// stopTimerHandler останавливает таймер пользователя по задаче id и возвращает завершённую запись
+func (s *Server) stopTimerHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entry, err := s.store.StopTimer(taskID, actorFromRequest(r), time.Now())
+	if err != nil {
+		writeJSONError(w, timeErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// getTimeEntriesHandler возвращает записи учёта времени по задаче id
+func (s *Server) getTimeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := s.store.ListTimeEntries(taskID)
+	if err != nil {
+		writeJSONError(w, timeErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TimeEntriesList{Entries: entries})
+}
+
+// postTimeEntryHandler учитывает по задаче id время, указанное вручную: {"minutes": N, "date": "20060102"}.
+// Без даты считается, что работа только что закончилась, с датой - что она началась в начале этого дня по UTC.
+func (s *Server) postTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Minutes int    `json:"minutes"`
+		Date    string `json:"date"`
+	}
+
+	taskID, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Minutes <= 0 || req.Minutes > maxManualEntryMinutes {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("поле Minutes должно быть от 1 до %d", maxManualEntryMinutes))
+		return
+	}
+
+	duration := time.Duration(req.Minutes) * time.Minute
+	startedAt := time.Now().UTC().Add(-duration)
+	if req.Date != "" {
+		date, err := time.Parse(utils.DateFormat, req.Date)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "поле Date имеет неверный формат")
+			return
+		}
+		startedAt = date
+	}
+
+	entry := models.TimeEntry{
+		TaskID:          taskID,
+		Actor:           actorFromRequest(r),
+		StartedAt:       startedAt.Format(time.RFC3339),
+		EndedAt:         startedAt.Add(duration).Format(time.RFC3339),
+		DurationSeconds: int64(duration / time.Second),
+	}
+	id, err := s.store.AddTimeEntry(entry)
+	if err != nil {
+		writeJSONError(w, timeErrorStatus(err), err.Error())
+		return
+	}
+	entry.ID = strconv.FormatInt(id, 10)
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// getTimeReportHandler возвращает учтённое и оценочное время по проектам и неделям.
+// Фильтры: project, from и to (даты начала записей в формате 20060102 по UTC, to включительно).
+func (s *Server) getTimeReportHandler(w http.ResponseWriter, r *http.Request) {
+	var filter models.TimeReportFilter
+
+	if r.URL.Query().Get("project") != "" {
+		projectID, err := parseIDParam(r, "project")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.ProjectID = projectID
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.From, filter.To = from, to
+
+	report, err := s.store.TimeReport(filter)
+	if err != nil {
+		writeJSONError(w, timeErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,42 @@
+package webserverutils
+
+import (
+	"errors"
+	"net/http"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+// getTrashHandler возвращает список задач в корзине
+func (s *Server) getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.store.ListTrash()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.TasksList{Tasks: tasks})
+}
+
+// restoreTaskHandler возвращает задачу из корзины
+func (s *Server) restoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	idParam, err := parseIDParam(r, "id")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err = s.store.Restore(idParam); err != nil {
+		if errors.Is(err, dbutils.ErrTaskNotFound) {
+			writeJSONError(w, http.StatusNotFound, "задача в корзине не найдена")
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.recordAudit(r, models.AuditActionRestore, idParam, nil, s.taskSnapshot(idParam))
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,40 @@
+package webserverutils
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+)
+
+// undoHandler отменяет последнее действие пользователя с задачами в пределах окна отмены
+func (s *Server) undoHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := s.store.UndoLast(actorFromRequest(r), time.Now().Add(-s.conf.UndoWindow))
+	if err != nil {
+		switch {
+		case errors.Is(err, dbutils.ErrNothingToUndo):
+			writeJSONError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, dbutils.ErrUndoConflict):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, dbutils.ErrTaskNotFound):
+			writeJSONError(w, http.StatusNotFound, "задача уже удалена окончательно")
+		default:
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// В журнале, как и для других действий, задача в корзине не имеет состояния
+	before, after := &result.Before, &result.Task
+	if before.DeletedAt != "" {
+		before = nil
+	}
+	if after.DeletedAt != "" {
+		after = nil
+	}
+	s.recordAudit(r, models.AuditActionUndo, result.Task.ID, before, after)
+
+	writeJSON(w, http.StatusOK, models.HTTPJSONUndoResponse{Undone: result.Action, Task: result.Task})
+}
@@ -0,0 +1,44 @@
+package webserverutils
+
+import (
+	"time"
+
+	models "webtasksplannerexample/internal/models"
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// NormalizeTask приводит задачу к сохраняемому виду и проверяет её. Используется при создании (POST /api/task,
+// быстрое добавление, шаблоны), изменении (PUT и PATCH /api/task) и импорте задач, поэтому одинаковые
+// данные везде обрабатываются одинаково:
+//   - дата нормализуется по правилам utils.NormalizeDate: пустая заменяется сегодняшней, сегодняшняя и будущая
+//     сохраняются, прошедшая заменяется сегодняшней (разовая задача) или следующей по правилу повторения;
+//   - поля проверяются taskFieldsValidate: заголовок обязателен, приоритет от 0 до 4, оценка от 0 до 99999 минут,
+//     статус пустой или один из статусов доски;
+//   - метки нормализуются utils.NormalizeTags.
+//
+// now - текущий момент, от которого отсчитываются "сегодня" и следующая дата повторения.
+func NormalizeTask(task models.Task, now time.Time) (models.Task, error) {
+	var err error
+
+	if task.Date, err = utils.NormalizeDate(now, task.Date, task.Repeat); err != nil {
+		return task, err
+	}
+
+	return normalizeTaskFields(task)
+}
+
+// normalizeTaskFields проверяет поля задачи и нормализует метки, не трогая дату.
+// Используется в PATCH /api/task, когда патч не меняет ни дату, ни правило повторения.
+func normalizeTaskFields(task models.Task) (models.Task, error) {
+	var err error
+
+	if err = taskFieldsValidate(task); err != nil {
+		return task, err
+	}
+
+	if task.Tags, err = utils.NormalizeTags(task.Tags); err != nil {
+		return task, err
+	}
+
+	return task, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	attachmentsutils "webtasksplannerexample/internal/attachments"
 	dbutils "webtasksplannerexample/internal/db"
 	models "webtasksplannerexample/internal/models"
 	utils "webtasksplannerexample/internal/utils"
@@ -20,30 +22,117 @@ import (
 
 const (
 	dateTimeFormat string = "20060102"
+
+	minTaskPriority = 1
+	maxTaskPriority = 4
+
+	maxTaskEstimateMinutes = 99999
 )
 
-func InitWebServer(conf models.ServiceConfig) error {
+// Server хранит зависимости обработчиков HTTP API
+type Server struct {
+	conf  models.ServiceConfig
+	store dbutils.Store
+	files *attachmentsutils.Storage
+}
+
+func NewServer(conf models.ServiceConfig, store dbutils.Store) *Server {
+	return &Server{
+		conf:  conf,
+		store: store,
+		files: attachmentsutils.NewStorage(conf.AttachmentsDir, conf.AttachmentMax),
+	}
+}
+
+// Router возвращает маршрутизатор со статикой и обработчиками API
+func (s *Server) Router() http.Handler {
 
 	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
 	router.Use(middleware.Logger)
 
-	FileServer(router, "/", http.Dir(conf.HTTPWebDir))
+	FileServer(router, "/", http.Dir(s.conf.HTTPWebDir))
 
 	router.Route("/api", func(r chi.Router) {
 		r.Get("/nextdate", getNextDateHandler)
 		r.Route("/task", func(rr chi.Router) {
-			rr.Post("/", postTaskHandler)
-			rr.Get("/", getTaskHandler)
-			rr.Put("/", putTaskHandler)
-			rr.Delete("/", deleteTaskHandler)
-			rr.Post("/done", doneTaskHandler)
+			rr.Post("/", s.postTaskHandler)
+			rr.Get("/", s.getTaskHandler)
+			rr.Put("/", s.putTaskHandler)
+			rr.Patch("/", s.patchTaskHandler)
+			rr.Delete("/", s.deleteTaskHandler)
+			rr.Post("/done", s.doneTaskHandler)
+			rr.Post("/restore", s.restoreTaskHandler)
+			rr.Post("/snooze", s.snoozeTaskHandler)
+			rr.Post("/quick", s.quickTaskHandler)
+			rr.Route("/checklist", func(rc chi.Router) {
+				rc.Get("/", s.getChecklistHandler)
+				rc.Post("/", s.postChecklistItemHandler)
+				rc.Delete("/", s.deleteChecklistItemHandler)
+				rc.Post("/toggle", s.toggleChecklistItemHandler)
+				rc.Post("/reorder", s.reorderChecklistHandler)
+			})
+			rr.Patch("/status", s.moveTaskHandler)
+			rr.Post("/timer/start", s.startTimerHandler)
+			rr.Post("/timer/stop", s.stopTimerHandler)
+			rr.Get("/time", s.getTimeEntriesHandler)
+			rr.Post("/time", s.postTimeEntryHandler)
+			rr.Route("/comments", func(rc chi.Router) {
+				rc.Get("/", s.getCommentsHandler)
+				rc.Post("/", s.postCommentHandler)
+				rc.Put("/", s.putCommentHandler)
+				rc.Delete("/", s.deleteCommentHandler)
+			})
+			rr.Route("/attachments", func(ra chi.Router) {
+				ra.Get("/", s.getAttachmentsHandler)
+				ra.Post("/", s.postAttachmentHandler)
+				ra.Delete("/", s.deleteAttachmentHandler)
+				ra.Get("/download", s.downloadAttachmentHandler)
+			})
+			rr.Post("/dependencies", s.addDependencyHandler)
+			rr.Delete("/dependencies", s.removeDependencyHandler)
 		})
 		r.Route("/tasks", func(rr chi.Router) {
-			rr.Get("/", getTasksHandler)
+			rr.Get("/", s.getTasksHandler)
+			rr.Post("/bulk", s.bulkTasksHandler)
+		})
+		r.Get("/tags", s.getTagsHandler)
+		r.Route("/projects", func(rr chi.Router) {
+			rr.Get("/", s.getProjectsHandler)
+			rr.Post("/", s.postProjectHandler)
+			rr.Get("/{id}", s.getProjectHandler)
+			rr.Put("/{id}", s.putProjectHandler)
+			rr.Delete("/{id}", s.deleteProjectHandler)
+			rr.Get("/{id}/columns", s.getColumnsHandler)
+			rr.Put("/{id}/columns", s.putColumnsHandler)
+		})
+		r.Route("/templates", func(rr chi.Router) {
+			rr.Get("/", s.getTemplatesHandler)
+			rr.Post("/", s.postTemplateHandler)
+			rr.Get("/{id}", s.getTemplateHandler)
+			rr.Put("/{id}", s.putTemplateHandler)
+			rr.Delete("/{id}", s.deleteTemplateHandler)
+			rr.Post("/{id}/instantiate", s.instantiateTemplateHandler)
 		})
+		r.Get("/board", s.getBoardHandler)
+		r.Get("/reports/time", s.getTimeReportHandler)
+		r.Get("/trash", s.getTrashHandler)
+		r.Get("/audit", s.getAuditHandler)
+		r.Get("/export", s.getExportHandler)
+		r.Post("/import", s.importHandler)
+		r.Get("/calendar.ics", s.getCalendarHandler)
+		r.Post("/calendar/token", s.postCalendarTokenHandler)
+		r.Delete("/calendar/token", s.deleteCalendarTokenHandler)
+		r.Post("/undo", s.undoHandler)
 	})
 
-	if err := http.ListenAndServe(":"+strconv.Itoa(conf.HTTPServerPort), router); err != nil {
+	return router
+}
+
+func InitWebServer(conf models.ServiceConfig, store dbutils.Store) error {
+	s := NewServer(conf, store)
+
+	if err := http.ListenAndServe(":"+strconv.Itoa(conf.HTTPServerPort), s.Router()); err != nil {
 		return err
 	}
 	return nil
@@ -69,16 +158,7 @@ func FileServer(r chi.Router, path string, root http.FileSystem) {
 	})
 }
 
-// Слайс из валидных регэкспов для поля repeat
-var repeatValidFormats = []string{
-	`^y$`, // Формат "y"
-	`^d\s(([1-9])|([1-9]\d)|[1-3]\d{2}|(400))$`, // Формат "d <число от 1 до 400>"
-	`^w\s[1-7](,[1-7]){0,6}$`,                   // Формат "w <числа от 1 до 7 через запятую>, при этом не более 7 штук
-	`^m\s((\-[12])|(0?[1-9])|([12]\d)|(3[01]))((,\-[12])|(,[1-9])|(,[12]\d)|(,3[01])){0,30}(\s((0?[1-9])|(1[012]))((,[1-9])|(,1[012])){0,11})?$`,
-	// Формат "m <числа от 1 до 31 через запятую>, при этом не более 31 штуки, числа 1 - 9 могут иметь написание 01 02 и т.д до 09
-	// далее опционально через пробел <числа от 1 до 12 через запятую> не более 12 штук
-}
-
+// TaskValidate проверяет изменяемую задачу: идентификатор и поля задачи (см. taskFieldsValidate)
 func TaskValidate(t models.FullTask) error {
 	if t.ID == "" {
 		return errors.New("некорректный формат поля ID")
@@ -86,6 +166,12 @@ func TaskValidate(t models.FullTask) error {
 		return errors.New("некорректный формат поля ID")
 	}
 
+	return taskFieldsValidate(t.Task)
+}
+
+// taskFieldsValidate проверяет поля задачи без изменения: заполненность даты и заголовка,
+// форматы даты и правила повторения, допустимые приоритет, оценку и статус
+func taskFieldsValidate(t models.Task) error {
 	if t.Date == "" {
 		return errors.New("поле Date должно быть заполнено")
 	} else if _, err := time.Parse(dateTimeFormat, t.Date); err != nil {
@@ -96,102 +182,65 @@ func TaskValidate(t models.FullTask) error {
 		return errors.New("поле Title должно быть заполнено")
 	}
 
-	if t.Repeat != "" && !utils.IsValidFormat(t.Repeat, repeatValidFormats) {
+	if t.Repeat != "" && !utils.IsValidFormat(t.Repeat, utils.RepeatValidFormats) {
 		return errors.New("поле Repeat имеет неверный формат")
 	}
 
-	return nil
+	if err := priorityValidate(t.Priority); err != nil {
+		return err
+	}
+
+	if err := estimateValidate(t.Estimate); err != nil {
+		return err
+	}
+
+	return statusValidate(t.Status, true)
 }
 
-// Функция для подсчета даты по правилам повтора
-func NextDate(now time.Time, date string, repeat string) (string, error) {
-	startDate, err := time.Parse(dateTimeFormat, date)
-	if err != nil {
-		return "", err
+// estimateValidate проверяет оценку трудоёмкости задачи в минутах, 0 - без оценки
+func estimateValidate(estimate *int) error {
+	if estimate != nil && (*estimate < 0 || *estimate > maxTaskEstimateMinutes) {
+		return fmt.Errorf("поле Estimate должно быть от 0 до %d минут", maxTaskEstimateMinutes)
 	}
-	if repeat == "" {
-		return "", fmt.Errorf("пустое значение repeat")
+	return nil
+}
+
+// statusValidate проверяет статус задачи, пустой статус допустим только при allowEmpty
+func statusValidate(status string, allowEmpty bool) error {
+	if status == "" && allowEmpty {
+		return nil
 	}
-	//валидируем repeat переменную
-	if !(utils.IsValidFormat(repeat, repeatValidFormats)) {
-		return "", fmt.Errorf("некорректный формат repeat")
+	if !slices.Contains(models.TaskStatuses, status) {
+		return fmt.Errorf("поле Status должно быть одним из: %s", strings.Join(models.TaskStatuses, ", "))
 	}
+	return nil
+}
 
-	substrs := strings.Split(repeat, " ")
-	switch substrs[0] {
-	case "y":
-		nextDate := startDate.AddDate(1, 0, 0)
-		for nextDate.Before(now) || nextDate.Equal(now) {
-			nextDate = nextDate.AddDate(1, 0, 0)
-		}
-		return nextDate.Format(dateTimeFormat), nil
-	case "d":
-		days, _ := strconv.Atoi(substrs[1])
-		nextDate := startDate.AddDate(0, 0, days)
-		for nextDate.Before(now) || nextDate.Equal(now) {
-			nextDate = nextDate.AddDate(0, 0, days)
-		}
-		return nextDate.Format(dateTimeFormat), nil
-	case "w":
-		repeatdays, err := utils.StringToInt(strings.Split(substrs[1], ","))
-		if err != nil {
-			return "", fmt.Errorf("неподдерживаемый формат")
-		}
-		var nextDateSlice []time.Time
-		for i := 0; i < len(repeatdays); i++ {
-			closestDate := startDate
-			if closestDate = utils.GetClosestWeekday(repeatdays[i], startDate); closestDate.Before(now) || closestDate.Equal(now) {
-				closestDate = utils.GetClosestWeekday(repeatdays[i], now)
-			}
-			nextDateSlice = append(nextDateSlice, closestDate)
-		}
-		return utils.FindMinDate(nextDateSlice).Format(dateTimeFormat), nil
-	case "m":
-		if len(substrs) == 2 {
-			repeatmonthdays, err := utils.StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[1], ","))
-			if err != nil {
-				return "", fmt.Errorf("неподдерживаемый формат")
-			}
-			var nextDateSlice []time.Time
-			for i := 0; i < len(repeatmonthdays); i++ {
-				closestDate := startDate
-				if closestDate = utils.GetClosesDateOfMonth(repeatmonthdays[i], int(startDate.Month()), startDate); closestDate.Before(now) || closestDate.Equal(now) {
-					if closestDate = utils.GetClosesDateOfMonth(repeatmonthdays[i], int(now.Month()), now); closestDate.Before(now) || closestDate.Equal(now) {
-						closestDate = utils.GetClosesDateOfMonth(repeatmonthdays[i], int(now.Month())+1, now)
-					}
-				}
-				nextDateSlice = append(nextDateSlice, closestDate)
-			}
-			return utils.FindMinDate(nextDateSlice).Format(dateTimeFormat), nil
-		} else if len(substrs) == 3 {
+// priorityValidate проверяет приоритет задачи: от 1 до 4, 0 - без приоритета
+func priorityValidate(priority *int) error {
+	if priority != nil && *priority != 0 && (*priority < minTaskPriority || *priority > maxTaskPriority) {
+		return fmt.Errorf("поле Priority должно быть от %d до %d", minTaskPriority, maxTaskPriority)
+	}
+	return nil
+}
 
-			repeatmonthdays, err := utils.StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[1], ","))
-			if err != nil {
-				return "", fmt.Errorf("неподдерживаемый формат")
-			}
-			repeatmonths, err := utils.StringSliceToIntSortAndRemoveDuplicates(strings.Split(substrs[2], ","))
-			if err != nil {
-				return "", fmt.Errorf("неподдерживаемый формат")
-			}
-			var nextDateSlice []time.Time
-			for i := 0; i < len(repeatmonthdays); i++ {
-				for j := 0; j < len(repeatmonths); j++ {
-					closestDate := startDate
-					if closestDate = utils.GetDateOfMonth(repeatmonthdays[i], repeatmonths[j], now, startDate); closestDate.Before(now) || closestDate.Equal(now) {
-						continue
-					} else {
-						nextDateSlice = append(nextDateSlice, closestDate)
-					}
-				}
-			}
-			fmt.Println(utils.FindMinDate(nextDateSlice).Format(dateTimeFormat))
-			return utils.FindMinDate(nextDateSlice).Format(dateTimeFormat), nil
-		}
+// parseTaskSort разбирает параметры sort (date, priority, title, created) и order (asc, desc) списка задач
+func parseTaskSort(sortParam, orderParam string) (models.TaskSort, error) {
+	sort := models.TaskSort{Field: sortParam}
+	switch sortParam {
+	case "", models.TaskSortDate, models.TaskSortPriority, models.TaskSortTitle, models.TaskSortCreated:
+	default:
+		return models.TaskSort{}, fmt.Errorf("неизвестное поле сортировки: %s", sortParam)
+	}
 
+	switch strings.ToLower(orderParam) {
+	case "", "asc":
+	case "desc":
+		sort.Desc = true
 	default:
-		return "", fmt.Errorf("неподдерживаемый формат")
+		return models.TaskSort{}, fmt.Errorf("неизвестный порядок сортировки: %s", orderParam)
 	}
-	return "", fmt.Errorf("unexpected error")
+	return sort, nil
 }
 
 func getNextDateHandler(w http.ResponseWriter, r *http.Request) {
@@ -203,7 +252,7 @@ func getNextDateHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Println("Ошибка при парсинге поля даты now", err.Error())
 	} else {
-		nextDate, err := NextDate(nowDate, dateStr, repeatStr)
+		nextDate, err := utils.NextDate(nowDate, dateStr, repeatStr)
 		if err != nil {
 			log.Println("Ошибка при вычислении nextdate", err.Error())
 		} else {
@@ -217,7 +266,19 @@ func getNextDateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func postTaskHandler(w http.ResponseWriter, r *http.Request) {
+// addTask сохраняет подготовленную задачу и записывает её создание в журнал
+func (s *Server) addTask(r *http.Request, task models.Task) (int64, error) {
+	id, err := s.store.Add(task)
+	if err != nil {
+		return 0, err
+	}
+
+	taskID := strconv.FormatInt(id, 10)
+	s.recordAudit(r, models.AuditActionCreate, taskID, nil, s.taskSnapshot(taskID))
+	return id, nil
+}
+
+func (s *Server) postTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var task models.Task
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
@@ -235,8 +296,9 @@ func postTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if task.Title == "" {
-		errorMsg := models.HTTPJSONErrorMessageResponse{Error: "Обязательное поле 'title' отсутствует"}
+	task, err := NormalizeTask(task, time.Now())
+	if err != nil {
+		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		errResp, _ := json.Marshal(errorMsg)
 		if _, err := w.Write(errResp); err != nil {
 			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
@@ -244,70 +306,63 @@ func postTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now, _ := time.Parse(dateTimeFormat, time.Now().Format(dateTimeFormat))
+	id, err := s.addTask(r, task)
 
-	if task.Date == "" {
-		task.Date = now.Format(dateTimeFormat)
-	}
-	date, err := time.Parse(dateTimeFormat, task.Date)
 	if err != nil {
-		errorMsg := models.HTTPJSONErrorMessageResponse{Error: "Дата имеет неверный формат"}
+		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		errResp, _ := json.Marshal(errorMsg)
 		if _, err := w.Write(errResp); err != nil {
 			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
 		}
 		return
+	} else {
+		respData := models.HTTPJSONResponseID{ID: id}
+		res, _ := json.Marshal(respData)
+		if _, err := w.Write(res); err != nil {
+			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
+		}
+		return
 	}
-	nextDate := ""
-	if task.Repeat != "" {
-		nextDate, err = NextDate(now, date.Format(dateTimeFormat), task.Repeat)
+}
+
+func (s *Server) getTasksHandler(w http.ResponseWriter, r *http.Request) {
+	//проверяем наличие и формат данных в поисковой строке
+	var filter models.TaskFilter
+	searchStr := r.URL.Query().Get("search")
+	searchDate, err := time.Parse("02.01.2006", searchStr)
+	if err == nil {
+		filter.Date = searchDate.Format(dateTimeFormat)
+	} else {
+		filter.Search = searchStr
+	}
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		filter.Tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	}
+	if r.URL.Query().Get("project") != "" {
+		projectID, err := parseIDParam(r, "project")
 		if err != nil {
 			errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 			errResp, _ := json.Marshal(errorMsg)
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 			if _, err := w.Write(errResp); err != nil {
 				http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
 			}
 			return
 		}
-	} else {
-		task.Date = now.Format(dateTimeFormat)
-	}
-
-	if date.Before(now) && nextDate != "" {
-		task.Date = nextDate
+		filter.ProjectID = projectID
 	}
-
-	id, err := dbutils.AddTask(task)
-
+	filter.Sort, err = parseTaskSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		errResp, _ := json.Marshal(errorMsg)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		if _, err := w.Write(errResp); err != nil {
 			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
 		}
 		return
-	} else {
-		respData := models.HTTPJSONResponseID{ID: id}
-		res, _ := json.Marshal(respData)
-		if _, err := w.Write(res); err != nil {
-			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
-		}
-		return
-	}
-}
-
-func getTasksHandler(w http.ResponseWriter, r *http.Request) {
-	//проверяем наличие и формат данных в поисковой строке
-	searchDateBool := false
-	searchStr := ""
-	searchStr = r.URL.Query().Get("search")
-	searchDate, err := time.Parse("02.01.2006", searchStr)
-	if err == nil {
-		searchDateBool = true
-		searchStr = searchDate.Format(dateTimeFormat)
 	}
 
-	tasks, err := dbutils.GetTasks(searchStr, searchDateBool)
+	tasks, err := s.store.List(filter)
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		errResp, _ := json.Marshal(errorMsg)
@@ -332,7 +387,7 @@ func getTasksHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func getTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) getTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	idParam := r.URL.Query().Get("id")
 
@@ -357,9 +412,9 @@ func getTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := dbutils.GetTaskByID(idParam)
+	task, err := s.store.Get(idParam)
 	if err != nil {
-		if err.Error() == "задача не найдена" {
+		if errors.Is(err, dbutils.ErrTaskNotFound) {
 			errorMsg := models.HTTPJSONErrorMessageResponse{Error: "задача не найдена"}
 			jsonResp, _ := json.Marshal(errorMsg)
 			if _, err := w.Write(jsonResp); err != nil {
@@ -381,7 +436,7 @@ func getTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func putTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) putTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var task models.FullTask
 
 	w.Header().Set("Content-Type", "application/json")
@@ -407,7 +462,16 @@ func putTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	currentTask, err := dbutils.GetTaskByID(task.ID)
+	if task.Task, err = NormalizeTask(task.Task, time.Now()); err != nil {
+		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
+		jsonResp, _ := json.Marshal(errorMsg)
+		if _, err := w.Write(jsonResp); err != nil {
+			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	currentTask, err := s.store.Get(task.ID)
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		jsonResp, _ := json.Marshal(errorMsg)
@@ -426,7 +490,7 @@ func putTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = dbutils.UpdateTask(task)
+	err = s.store.Update(task)
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		jsonResp, _ := json.Marshal(errorMsg)
@@ -436,6 +500,8 @@ func putTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAudit(r, models.AuditActionUpdate, task.ID, &currentTask, s.taskSnapshot(task.ID))
+
 	// Возвращаем пустой JSON-объект в случае успеха
 	if _, err := w.Write([]byte("{}")); err != nil {
 		http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
@@ -443,7 +509,7 @@ func putTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func doneTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) doneTaskHandler(w http.ResponseWriter, r *http.Request) {
 	idParam := r.URL.Query().Get("id")
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -467,17 +533,9 @@ func doneTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	currentTask, err := dbutils.GetTaskByID(idParam)
+	currentTask, err := s.store.Get(idParam)
 	if err != nil {
-		if err.Error() == "задача не найдена" {
-			errorMsg := models.HTTPJSONErrorMessageResponse{Error: "задача не найдена"}
-			jsonResp, _ := json.Marshal(errorMsg)
-			if _, err := w.Write(jsonResp); err != nil {
-				http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
-			}
-			return
-		}
-		errorMsg := models.HTTPJSONErrorMessageResponse{Error: "задача не найдена"}
+		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		jsonResp, _ := json.Marshal(errorMsg)
 		if _, err := w.Write(jsonResp); err != nil {
 			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
@@ -485,25 +543,15 @@ func doneTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if currentTask.Repeat == "" {
-		err = dbutils.DeleteTaskByID(idParam)
-		if err != nil {
-			errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
-			jsonResp, _ := json.Marshal(errorMsg)
-			if _, err := w.Write(jsonResp); err != nil {
-				http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
-			}
-			return
-		}
-
-		if _, err := w.Write([]byte("{}")); err != nil {
-			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
-		}
+	// Задачу с невыполненными блокирующими задачами можно завершить только явно, с force=true
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); len(currentTask.BlockedBy) > 0 && !force {
+		writeJSONError(w, http.StatusConflict,
+			"задача заблокирована невыполненными задачами: "+strings.Join(currentTask.BlockedBy, ", "))
 		return
 	}
 
 	now, _ := time.Parse(dateTimeFormat, time.Now().Format(dateTimeFormat))
-	nextDate, err := NextDate(now, currentTask.Date, currentTask.Repeat)
+	err = s.store.Complete(idParam, now)
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		jsonResp, _ := json.Marshal(errorMsg)
@@ -513,25 +561,7 @@ func doneTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task := models.FullTask{
-		ID: currentTask.ID,
-		Task: models.Task{
-			Date:    nextDate,
-			Title:   currentTask.Title,
-			Comment: currentTask.Comment,
-			Repeat:  currentTask.Repeat,
-		},
-	}
-
-	err = dbutils.UpdateTask(task)
-	if err != nil {
-		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
-		jsonResp, _ := json.Marshal(errorMsg)
-		if _, err := w.Write(jsonResp); err != nil {
-			http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
-		}
-		return
-	}
+	s.recordAudit(r, models.AuditActionDone, idParam, &currentTask, s.taskSnapshot(idParam))
 
 	// Возвращаем пустой JSON-объект в случае успеха
 	if _, err := w.Write([]byte("{}")); err != nil {
@@ -540,7 +570,7 @@ func doneTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	idParam := r.URL.Query().Get("id")
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -564,7 +594,9 @@ func deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = dbutils.DeleteTaskByID(idParam)
+	currentTask := s.taskSnapshot(idParam)
+
+	err = s.store.Delete(idParam)
 	if err != nil {
 		errorMsg := models.HTTPJSONErrorMessageResponse{Error: err.Error()}
 		jsonResp, _ := json.Marshal(errorMsg)
@@ -574,6 +606,8 @@ func deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAudit(r, models.AuditActionDelete, idParam, currentTask, nil)
+
 	// Возвращаем пустой JSON-объект в случае успеха
 	if _, err := w.Write([]byte("{}")); err != nil {
 		http.Error(w, "ошибка записи ответа", http.StatusInternalServerError)
@@ -14,6 +14,11 @@ import (
 )
 
 func getURL(path string) string {
+	path = strings.TrimPrefix(strings.ReplaceAll(path, `\`, `/`), `../web/`)
+	if !External {
+		return serverURL + "/" + path
+	}
+
 	port := Port
 	envPort := os.Getenv("TODO_PORT")
 	if len(envPort) > 0 {
@@ -21,7 +26,6 @@ func getURL(path string) string {
 			port = int(eport)
 		}
 	}
-	path = strings.TrimPrefix(strings.ReplaceAll(path, `\`, `/`), `../web/`)
 	return fmt.Sprintf("http://localhost:%d/%s", port, path)
 }
 
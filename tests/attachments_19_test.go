@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	attachmentsutils "webtasksplannerexample/internal/attachments"
+	dbutils "webtasksplannerexample/internal/db"
+)
+
+// uploadAttachment отправляет файл вложением задачи taskID
+func uploadAttachment(t *testing.T, taskID string, fileName string, content []byte) (int, map[string]any) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	resp, err := http.Post(getURL("api/task/attachments?task_id="+taskID), form.FormDataContentType(), &body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var m map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	return resp.StatusCode, m
+}
+
+func attachmentFiles(t *testing.T) int {
+	entries, err := os.ReadDir(attachmentsDir)
+	assert.NoError(t, err)
+	return len(entries)
+}
+
+func TestAttachments(t *testing.T) {
+	if External {
+		t.Skip("каталог вложений внешнего сервера недоступен тесту")
+	}
+
+	ret, err := postJSON("api/task", map[string]any{"date": time.Now().Format(`20060102`), "title": "Разобрать логи"}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(ret["id"])
+	files := attachmentFiles(t)
+
+	code, ret := uploadAttachment(t, taskID, "../../журнал.txt", []byte("строка журнала\n"))
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "журнал.txt", ret["file_name"])
+	assert.Equal(t, "text/plain; charset=utf-8", ret["content_type"])
+	assert.Equal(t, float64(len("строка журнала\n")), ret["size"])
+	id := fmt.Sprint(ret["id"])
+	assert.Equal(t, files+1, attachmentFiles(t))
+
+	code, ret = uploadAttachment(t, taskID, "pixel.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "image/png", ret["content_type"])
+
+	code, _ = uploadAttachment(t, taskID, "big.bin", bytes.Repeat([]byte{1}, int(attachmentMax)+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	code, _ = uploadAttachment(t, taskID, "empty.txt", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = uploadAttachment(t, "999999", "file.txt", []byte("текст"))
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, files+2, attachmentFiles(t))
+
+	code, ret = requestStatus(t, "api/task/attachments?task_id="+taskID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, ret["attachments"], 2)
+
+	resp, err := http.Get(getURL("api/task/attachments/download?id=" + id))
+	assert.NoError(t, err)
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "строка журнала\n", string(content))
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Disposition"), "attachment;"))
+
+	code, _ = requestStatus(t, "api/task/attachments?id="+id, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/task/attachments?id="+id, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, files+1, attachmentFiles(t))
+
+	// Файлы вложений удаляются вместе с задачей при очистке корзины
+	ret, err = postJSON("api/task?id="+taskID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	store, err := dbutils.NewMemoryStore(memoryDBName)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	attachmentsutils.RemoveOrphans(store, attachmentsutils.NewStorage(attachmentsDir, attachmentMax))
+	assert.Equal(t, files+1, attachmentFiles(t), "вложения задачи в корзине сохраняются")
+
+	_, err = store.PurgeTrash(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	attachmentsutils.RemoveOrphans(store, attachmentsutils.NewStorage(attachmentsDir, attachmentMax))
+	assert.Equal(t, files, attachmentFiles(t))
+}
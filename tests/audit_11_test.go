@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dbutils "webtasksplannerexample/internal/db"
+	"webtasksplannerexample/internal/models"
+)
+
+// requestAs выполняет запрос к API от имени пользователя actor
+func requestAs(t *testing.T, actor string, apipath string, values map[string]any, method string) map[string]any {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", actor)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m
+}
+
+type auditEntry struct {
+	TaskID    string         `json:"task_id"`
+	Actor     string         `json:"actor"`
+	Action    string         `json:"action"`
+	Before    map[string]any `json:"before"`
+	After     map[string]any `json:"after"`
+	RequestID string         `json:"request_id"`
+	CreatedAt string         `json:"created_at"`
+}
+
+func getAudit(t *testing.T, query string) []auditEntry {
+	body, err := requestJSON("api/audit?"+query, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m struct {
+		Entries []auditEntry `json:"entries"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m.Entries
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	ret := requestAs(t, "alice", "api/task", map[string]any{
+		"date":  today,
+		"title": "Задача под аудитом",
+	}, http.MethodPost)
+	assert.NotNil(t, ret["id"])
+	id := fmt.Sprint(ret["id"])
+
+	ret = requestAs(t, "bob", "api/task", map[string]any{
+		"id":    id,
+		"date":  now.AddDate(0, 0, 2).Format(`20060102`),
+		"title": "Задача под аудитом",
+	}, http.MethodPut)
+	assert.Empty(t, ret)
+
+	ret = requestAs(t, "bob", "api/task?id="+id, nil, http.MethodDelete)
+	assert.Empty(t, ret)
+
+	entries := getAudit(t, "task_id="+id)
+	assert.Len(t, entries, 3)
+	if len(entries) != 3 {
+		return
+	}
+
+	// Записи идут от последних к первым
+	del, upd, create := entries[0], entries[1], entries[2]
+
+	assert.Equal(t, "create", create.Action)
+	assert.Equal(t, "alice", create.Actor)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, today, create.After["date"])
+	assert.NotEmpty(t, create.RequestID)
+	assert.NotEmpty(t, create.CreatedAt)
+
+	assert.Equal(t, "update", upd.Action)
+	assert.Equal(t, "bob", upd.Actor)
+	assert.Equal(t, today, upd.Before["date"])
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), upd.After["date"])
+
+	assert.Equal(t, "delete", del.Action)
+	assert.NotNil(t, del.Before)
+	assert.Nil(t, del.After)
+
+	entries = getAudit(t, "task_id="+id+"&actor=bob&action=update")
+	assert.Len(t, entries, 1)
+
+	entries = getAudit(t, "task_id="+id+"&from="+today+"&to="+today)
+	assert.Len(t, entries, 3)
+	entries = getAudit(t, "task_id="+id+"&from="+now.AddDate(0, 0, 2).Format(`20060102`))
+	assert.Empty(t, entries)
+
+	entries = getAudit(t, "task_id="+id+"&limit=1")
+	assert.Len(t, entries, 1)
+
+	body, err := requestJSON("api/audit?from=ooops", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.NotEmpty(t, m["error"])
+}
+
+func TestAuditLimit(t *testing.T) {
+	store, err := dbutils.NewMemoryStore("audit_limit_test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+
+	for i := 0; i < 520; i++ {
+		assert.NoError(t, store.AddAuditEntry(models.AuditEntry{TaskID: "1", Actor: "alice", Action: "update"}))
+	}
+
+	tbl := []struct {
+		limit int
+		want  int
+	}{
+		{0, 50},
+		{1, 1},
+		{120, 120},
+		{500, 500},
+		// Лимит больше максимального ограничивается максимальным, а не значением по умолчанию
+		{1000, 500},
+	}
+	for _, v := range tbl {
+		entries, err := store.ListAuditEntries(models.AuditFilter{Limit: v.limit})
+		assert.NoError(t, err)
+		assert.Len(t, entries, v.want, "limit %d", v.limit)
+	}
+}
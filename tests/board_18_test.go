@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// boardState возвращает доску проекта в виде "статус: названия задач по порядку"
+func boardState(t *testing.T, projectID string) []string {
+	code, ret := requestStatus(t, "api/board?project="+projectID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+
+	state := []string{}
+	for _, v := range ret["columns"].([]any) {
+		column := v.(map[string]any)
+		line := fmt.Sprint(column["status"], ":")
+		for _, task := range column["tasks"].([]any) {
+			line += " " + task.(map[string]any)["title"].(string)
+		}
+		state = append(state, line)
+	}
+	return state
+}
+
+func TestBoard(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	code, ret := requestStatus(t, "api/projects", map[string]any{"name": "Доска"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := fmt.Sprint(ret["id"])
+
+	add := func(title string, status string) string {
+		values := map[string]any{"date": today, "title": title, "project_id": projectID}
+		if status != "" {
+			values["status"] = status
+		}
+		ret, err := postJSON("api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		return fmt.Sprint(ret["id"])
+	}
+	a := add("A", "")
+	b := add("B", "")
+	c := add("C", "in_progress")
+
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "X", "status": "later"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	assert.Equal(t, []string{"todo: A B", "in_progress: C", "waiting:", "done:"}, boardState(t, projectID))
+
+	move := func(id, status string, position int) int {
+		code, _ := requestStatus(t, "api/task/status?id="+id, map[string]any{"status": status, "position": position}, http.MethodPatch)
+		return code
+	}
+	assert.Equal(t, http.StatusOK, move(b, "in_progress", 0))
+	assert.Equal(t, http.StatusOK, move(a, "in_progress", 10))
+	assert.Equal(t, []string{"todo:", "in_progress: B C A", "waiting:", "done:"}, boardState(t, projectID))
+	assert.Equal(t, http.StatusOK, move(a, "in_progress", 1))
+	assert.Equal(t, []string{"todo:", "in_progress: B A C", "waiting:", "done:"}, boardState(t, projectID))
+	assert.Equal(t, http.StatusBadRequest, move(a, "blocked", 0))
+	assert.Equal(t, http.StatusNotFound, move("999999", "done", 0))
+
+	task, err := postJSON("api/task?id="+a, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "in_progress", task["status"])
+
+	// Колонки проекта настраиваются, перенос в статус без колонки запрещён
+	code, ret = requestStatus(t, "api/projects/"+projectID+"/columns", map[string]any{
+		"columns": []map[string]any{
+			{"status": "in_progress", "title": "Делаем"},
+			{"status": "done"},
+		},
+	}, http.MethodPut)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"status": "done", "title": "Готово"}, ret["columns"].([]any)[1])
+	assert.Equal(t, http.StatusConflict, move(c, "waiting", 0))
+	assert.Equal(t, http.StatusOK, move(c, "done", 0))
+
+	// Задачи со статусом без колонки показываются в конце первой колонки
+	add("D", "todo")
+	assert.Equal(t, []string{"in_progress: B A D", "done: C"}, boardState(t, projectID))
+
+	code, _ = requestStatus(t, "api/projects/"+projectID+"/columns", map[string]any{
+		"columns": []map[string]any{{"status": "done"}, {"status": "done"}},
+	}, http.MethodPut)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = requestStatus(t, "api/projects/"+projectID+"/columns", map[string]any{"columns": []any{}}, http.MethodPut)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"todo: D", "in_progress: B A", "waiting:", "done: C"}, boardState(t, projectID))
+}
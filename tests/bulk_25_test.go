@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bulkResults(t *testing.T, ret map[string]any) []string {
+	results := []string{}
+	for _, v := range ret["results"].([]any) {
+		result := v.(map[string]any)
+		results = append(results, fmt.Sprintf("%v:%v", result["op"], result["ok"]))
+	}
+	return results
+}
+
+func TestBulk(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	ids := []string{}
+	for _, title := range []string{"Разобрать почту", "Ответить коллегам", "Сдать отчёт"} {
+		ret, err := postJSON("api/task", map[string]any{"date": today, "title": title}, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+	}
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "Планёрка", "repeat": "d 1"}, http.MethodPost)
+	assert.NoError(t, err)
+	repeatID := fmt.Sprint(ret["id"])
+
+	code, _ := requestStatus(t, "api/tasks/bulk", map[string]any{"operations": []any{}}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/tasks/bulk", map[string]any{
+		"mode": "maybe", "operations": []any{map[string]any{"op": "done", "id": ids[0]}},
+	}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Ошибка в одной операции отменяет весь пакет
+	code, ret = requestStatus(t, "api/tasks/bulk", map[string]any{"operations": []any{
+		map[string]any{"op": "tag", "id": ids[0], "tag": "#Отпуск"},
+		map[string]any{"op": "delete", "id": ids[1]},
+		map[string]any{"op": "done", "id": "999999"},
+	}}, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, float64(0), ret["applied"])
+	assert.Equal(t, []string{"tag:false", "delete:false", "done:false"}, bulkResults(t, ret))
+	task, err := postJSON("api/task?id="+ids[1], nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Empty(t, task["error"])
+	task, err = postJSON("api/task?id="+ids[0], nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Empty(t, task["tags"])
+
+	// Некорректная операция тоже отменяет пакет, не доходя до хранилища
+	code, ret = requestStatus(t, "api/tasks/bulk", map[string]any{"operations": []any{
+		map[string]any{"op": "delete", "id": ids[1]},
+		map[string]any{"op": "move", "id": ids[2]},
+	}}, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, []string{"delete:false", "move:false"}, bulkResults(t, ret))
+
+	code, ret = requestStatus(t, "api/tasks/bulk", map[string]any{"operations": []any{
+		map[string]any{"op": "tag", "id": ids[0], "tag": "#Отпуск"},
+		map[string]any{"op": "move", "id": ids[0], "date": now.AddDate(0, 0, 5).Format(`20060102`)},
+		map[string]any{"op": "delete", "id": ids[1]},
+		map[string]any{"op": "move", "id": repeatID, "by": "1w"},
+		map[string]any{"op": "done", "id": ids[2]},
+	}}, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(5), ret["applied"])
+
+	task, err = postJSON("api/task?id="+ids[0], nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"отпуск"}, task["tags"])
+	assert.Equal(t, now.AddDate(0, 0, 5).Format(`20060102`), task["date"])
+	task, err = postJSON("api/task?id="+repeatID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), task["date"])
+	for _, id := range ids[1:] {
+		task, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, task["error"])
+	}
+	assert.Len(t, getAudit(t, "task_id="+ids[1]+"&action=delete"), 1)
+	assert.Len(t, getAudit(t, "task_id="+repeatID+"&action=snooze"), 1)
+
+	// В режиме best-effort выполняется всё, что возможно
+	code, ret = requestStatus(t, "api/tasks/bulk", map[string]any{"mode": "best-effort", "operations": []any{
+		map[string]any{"op": "done", "id": ids[1]},
+		map[string]any{"op": "rename", "id": ids[0]},
+		map[string]any{"op": "done", "id": repeatID},
+		map[string]any{"op": "move", "id": ids[0], "by": "2d"},
+	}}, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), ret["applied"])
+	assert.Equal(t, []string{"done:false", "rename:false", "done:true", "move:false"}, bulkResults(t, ret))
+	for _, v := range ret["results"].([]any) {
+		result := v.(map[string]any)
+		if result["ok"] == false {
+			assert.NotEmpty(t, result["error"])
+		}
+	}
+	task, err = postJSON("api/task?id="+repeatID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 8).Format(`20060102`), task["date"])
+}
+
+func TestBulkSameTask(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	tomorrow := now.AddDate(0, 0, 1).Format(`20060102`)
+
+	ret := requestAs(t, "bulker", "api/task", map[string]any{"date": today, "title": "Зарядка", "repeat": "d 1"}, http.MethodPost)
+	id := fmt.Sprint(ret["id"])
+
+	code, ret := requestStatusAs(t, "bulker", "api/tasks/bulk", map[string]any{"operations": []any{
+		map[string]any{"op": "done", "id": id},
+		map[string]any{"op": "tag", "id": id, "tag": "спорт"},
+	}}, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), ret["applied"])
+
+	// У каждой операции над задачей в журнале своё изменение: вторая начинается с итога первой
+	done := getAudit(t, "task_id="+id+"&action=done")
+	if assert.Len(t, done, 1) {
+		assert.Equal(t, today, done[0].Before["date"])
+		assert.Equal(t, tomorrow, done[0].After["date"])
+		assert.Nil(t, done[0].After["tags"])
+	}
+	update := getAudit(t, "task_id="+id+"&action=update")
+	if assert.Len(t, update, 1) {
+		assert.Equal(t, tomorrow, update[0].Before["date"])
+		assert.Nil(t, update[0].Before["tags"])
+		assert.Equal(t, []any{"спорт"}, update[0].After["tags"])
+	}
+
+	// Отмена возвращает операции пакета по одной
+	action, task := undoAs(t, "bulker")
+	assert.Equal(t, "update", action)
+	assert.Equal(t, tomorrow, task["date"])
+	assert.Nil(t, task["tags"])
+
+	action, task = undoAs(t, "bulker")
+	assert.Equal(t, "done", action)
+	assert.Equal(t, today, task["date"])
+}
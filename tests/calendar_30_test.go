@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// getCalendar возвращает код ответа и ленту календаря с объединёнными перенесёнными строками
+func getCalendar(t *testing.T, query string) (int, string) {
+	resp, err := http.Get(getURL("api/calendar.ics?" + query))
+	if !assert.NoError(t, err) {
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	if resp.StatusCode == http.StatusOK {
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+	}
+	return resp.StatusCode, strings.ReplaceAll(string(body), "\r\n ", "")
+}
+
+func TestRepeatRRule(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		want   string
+	}{
+		{"", ""},
+		{"y", "FREQ=YEARLY"},
+		{"d 1", "FREQ=DAILY"},
+		{"d 14", "FREQ=DAILY;INTERVAL=14"},
+		{"w 5,1,3", "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{"w 7", "FREQ=WEEKLY;BYDAY=SU"},
+		{"m 05,-1", "FREQ=MONTHLY;BYMONTHDAY=-1,5"},
+		{"m 1,15 06,1", "FREQ=YEARLY;BYMONTH=1,6;BYMONTHDAY=1,15"},
+	}
+	for _, v := range tbl {
+		got, err := utils.RepeatRRule(v.repeat)
+		assert.NoError(t, err, v.repeat)
+		assert.Equal(t, v.want, got, v.repeat)
+	}
+
+	_, err := utils.RepeatRRule("x 1")
+	assert.Error(t, err)
+}
+
+func TestICalLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("Длинное описание задачи, ", 10)
+	folded := utils.ICalLine(line)
+
+	assert.True(t, strings.HasSuffix(folded, "\r\n"))
+	for _, part := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(part), 75)
+	}
+	assert.Equal(t, line+"\r\n", strings.ReplaceAll(folded, "\r\n ", ""))
+
+	assert.Equal(t, `Купить: хлеб\, молоко\; сыр\nи \\ масло`, utils.ICalText("Купить: хлеб, молоко; сыр\nи \\ масло"))
+}
+
+func TestCalendarFeed(t *testing.T) {
+	now := time.Now()
+	future := now.AddDate(0, 0, 7)
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date": future.Format(`20060102`), "title": "Тренировка", "comment": "Зал, потом бассейн",
+		"repeat": "w 1,5", "tags": []string{"спорт"}, "priority": 2,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	repeatID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task", map[string]any{"date": future.Format(`20060102`), "title": "Сдать анализы"}, http.MethodPost)
+	assert.NoError(t, err)
+	onceID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task", map[string]any{"date": future.Format(`20060102`), "title": "Удалённая задача"}, http.MethodPost)
+	assert.NoError(t, err)
+	deletedID := fmt.Sprint(ret["id"])
+	_, err = requestJSON("api/task?id="+deletedID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+
+	code, _ := getCalendar(t, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = getCalendar(t, "token=unknown")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = requestStatus(t, "api/calendar/token", nil, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, ret = requestStatusAs(t, "ольга", "api/calendar/token", nil, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	token := fmt.Sprint(ret["token"])
+	assert.Len(t, token, 64)
+	assert.Equal(t, "/api/calendar.ics?token="+token, ret["url"])
+
+	code, feed := getCalendar(t, "token="+token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Contains(t, feed, "UID:task-"+repeatID+"@webtasksplanner\r\n")
+	assert.Contains(t, feed, "UID:task-"+onceID+"@webtasksplanner\r\n")
+	assert.NotContains(t, feed, "UID:task-"+deletedID+"@")
+	assert.Contains(t, feed, "RRULE:FREQ=WEEKLY;BYDAY=MO,FR\r\n")
+	assert.Contains(t, feed, "DESCRIPTION:Зал\\, потом бассейн\r\n")
+	assert.Contains(t, feed, "CATEGORIES:спорт\r\n")
+	assert.Contains(t, feed, "PRIORITY:3\r\n")
+
+	// Разовая задача - событие на весь день даты задачи
+	event := feed[strings.Index(feed, "UID:task-"+onceID+"@"):]
+	event = event[:strings.Index(event, "END:VEVENT")]
+	assert.Contains(t, event, "DTEND;VALUE=DATE:"+future.AddDate(0, 0, 1).Format(`20060102`))
+	assert.NotContains(t, event, "RRULE")
+
+	code, feed = getCalendar(t, "type=todo&token="+token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, feed, "BEGIN:VTODO\r\n")
+	assert.NotContains(t, feed, "BEGIN:VEVENT")
+	assert.Contains(t, feed, "DUE;VALUE=DATE:"+future.Format(`20060102`))
+	assert.Contains(t, feed, "STATUS:NEEDS-ACTION\r\n")
+
+	code, _ = getCalendar(t, "type=journal&token="+token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Новый токен заменяет прежний
+	code, ret = requestStatusAs(t, "ольга", "api/calendar/token", nil, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	newToken := fmt.Sprint(ret["token"])
+	code, _ = getCalendar(t, "token="+token)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = getCalendar(t, "token="+newToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = requestStatusAs(t, "ольга", "api/calendar/token", nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = getCalendar(t, "token="+newToken)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = requestStatusAs(t, "ольга", "api/calendar/token", nil, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// checklistState возвращает пункты чек-листа задачи в виде "название:отметка" по порядку
+func checklistState(t *testing.T, taskID string) []string {
+	code, ret := requestStatus(t, "api/task/checklist?task_id="+taskID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+
+	state := []string{}
+	for _, v := range ret["items"].([]any) {
+		item := v.(map[string]any)
+		state = append(state, fmt.Sprintf("%s:%v", item["title"], item["done"]))
+	}
+	return state
+}
+
+func TestChecklist(t *testing.T) {
+	now := time.Now()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":   now.Format(`20060102`),
+		"title":  "Подготовить релиз",
+		"repeat": "d 7",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(ret["id"])
+
+	ids := []string{}
+	for _, title := range []string{"Заморозить ветку", "Собрать сборку", "Опубликовать"} {
+		code, ret := requestStatus(t, "api/task/checklist?task_id="+taskID, map[string]any{"title": title}, http.MethodPost)
+		assert.Equal(t, http.StatusCreated, code)
+		ids = append(ids, fmt.Sprint(ret["id"]))
+	}
+	code, _ := requestStatus(t, "api/task/checklist?task_id="+taskID, map[string]any{"title": "  "}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/task/checklist?task_id=999999", map[string]any{"title": "Пункт"}, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, ret = requestStatus(t, "api/task/checklist/toggle?id="+ids[0], nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, ret["done"])
+	code, _ = requestStatus(t, "api/task/checklist/toggle?id="+ids[1], nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = requestStatus(t, "api/task/checklist/reorder?task_id="+taskID,
+		map[string]any{"ids": []string{ids[2], ids[0], ids[1]}}, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Опубликовать:false", "Заморозить ветку:true", "Собрать сборку:true"}, checklistState(t, taskID))
+
+	code, _ = requestStatus(t, "api/task/checklist/reorder?task_id="+taskID,
+		map[string]any{"ids": []string{ids[2], ids[0]}}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, task["checklist"], 3)
+
+	// Выполнение повторяющейся задачи снимает отметки с пунктов чек-листа
+	ret, err = postJSON("api/task/done?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, []string{"Опубликовать:false", "Заморозить ветку:false", "Собрать сборку:false"}, checklistState(t, taskID))
+
+	code, _ = requestStatus(t, "api/task/checklist?id="+ids[2], nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/task/checklist?id="+ids[2], nil, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, []string{"Заморозить ветку:false", "Собрать сборку:false"}, checklistState(t, taskID))
+}
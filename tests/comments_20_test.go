@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComments(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "Обсудить бюджет"}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(ret["id"])
+
+	code, ret := requestStatusAs(t, "alice", "api/task/comments?task_id="+taskID, map[string]any{"text": " Предлагаю 100 "}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	aliceComment := fmt.Sprint(ret["id"])
+	code, ret = requestStatusAs(t, "bob", "api/task/comments?task_id="+taskID, map[string]any{"text": "Согласен"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	bobComment := fmt.Sprint(ret["id"])
+
+	code, _ = requestStatusAs(t, "bob", "api/task/comments?task_id="+taskID, map[string]any{"text": ""}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatusAs(t, "bob", "api/task/comments?task_id=999999", map[string]any{"text": "Кто здесь?"}, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, ret = requestStatus(t, "api/task/comments?task_id="+taskID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	comments := ret["comments"].([]any)
+	assert.Len(t, comments, 2)
+	first := comments[0].(map[string]any)
+	assert.Equal(t, "alice", first["author"])
+	assert.Equal(t, "Предлагаю 100", first["text"])
+	assert.NotEmpty(t, first["created_at"])
+	assert.Nil(t, first["updated_at"])
+
+	// Количество комментариев видно в списке задач
+	body, err := requestJSON("api/tasks?search="+url.QueryEscape("Обсудить бюджет"), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var list struct {
+		Tasks []map[string]any `json:"tasks"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &list))
+	assert.Len(t, list.Tasks, 1)
+	assert.Equal(t, float64(2), list.Tasks[0]["comment_count"])
+
+	// Изменять и удалять комментарий может только автор
+	code, _ = requestStatusAs(t, "bob", "api/task/comments?id="+aliceComment, map[string]any{"text": "Предлагаю 0"}, http.MethodPut)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, ret = requestStatusAs(t, "alice", "api/task/comments?id="+aliceComment, map[string]any{"text": "Предлагаю 120"}, http.MethodPut)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Предлагаю 120", ret["text"])
+	assert.NotEmpty(t, ret["updated_at"])
+
+	code, _ = requestStatusAs(t, "alice", "api/task/comments?id="+bobComment, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = requestStatusAs(t, "bob", "api/task/comments?id="+bobComment, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatusAs(t, "bob", "api/task/comments?id="+bobComment, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), task["comment_count"])
+}
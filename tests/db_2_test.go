@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"database/sql"
 	"os"
 	"testing"
 	"time"
@@ -8,6 +9,8 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+
+	dbutils "webtasksplannerexample/internal/db"
 )
 
 type Task struct {
@@ -16,6 +19,14 @@ type Task struct {
 	Title   string `db:"title"`
 	Comment string `db:"comment"`
 	Repeat  string `db:"repeat"`
+
+	DeletedAt       sql.NullString `db:"deleted_at"`
+	ProjectID       sql.NullInt64  `db:"project_id"`
+	Priority        int            `db:"priority"`
+	CreatedAt       string         `db:"created_at"`
+	Status          string         `db:"status"`
+	Position        int            `db:"position"`
+	EstimateMinutes int            `db:"estimate_minutes"`
 }
 
 func count(db *sqlx.DB) (int, error) {
@@ -24,6 +35,13 @@ func count(db *sqlx.DB) (int, error) {
 }
 
 func openDB(t *testing.T) *sqlx.DB {
+	if !External {
+		// База сервера, запущенного внутри процесса, доступна по имени через драйвер modernc.org/sqlite
+		db, err := sqlx.Connect("sqlite", dbutils.MemoryDSN(memoryDBName))
+		assert.NoError(t, err)
+		return db
+	}
+
 	dbfile := DBFile
 	envFile := os.Getenv("TODO_DBFILE")
 	if len(envFile) > 0 {
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	add := func(title string) string {
+		ret, err := postJSON("api/task", map[string]any{"date": today, "title": title}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		return fmt.Sprint(ret["id"])
+	}
+	design := add("Согласовать макет")
+	build := add("Сверстать страницу")
+	deploy := add("Выложить страницу")
+
+	dependency := func(taskID, blockerID, method string) (int, map[string]any) {
+		return requestStatus(t, "api/task/dependencies?id="+taskID+"&blocker_id="+blockerID, nil, method)
+	}
+
+	code, ret := dependency(build, design, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{design}, ret["blocked_by"])
+	code, _ = dependency(deploy, build, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Прямые и косвенные циклы отклоняются
+	code, _ = dependency(design, design, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = dependency(build, deploy, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = dependency(design, deploy, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = dependency(design, "999999", http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, ret = requestStatus(t, "api/task/done?id="+build, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.NotEmpty(t, ret["error"])
+
+	// После выполнения блокирующей задачи блокировка снимается
+	code, ret = requestStatus(t, "api/task/done?id="+design, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, ret)
+	task, err := postJSON("api/task?id="+build, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, task["blocked_by"])
+	code, _ = requestStatus(t, "api/task/done?id="+build, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Снятие зависимости и принудительное выполнение
+	blocker := add("Получить доступы")
+	code, _ = dependency(deploy, blocker, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	code, ret = dependency(deploy, build, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{blocker}, ret["blocked_by"])
+	code, _ = dependency(deploy, build, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = requestStatus(t, "api/task/done?id="+deploy, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = requestStatus(t, "api/task/done?id="+deploy+"&force=true", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Повторяющаяся задача при выполнении не попадает в корзину, но блокировку снимает
+	ret, err = postJSON("api/task", map[string]any{"date": today, "title": "Еженедельный созвон", "repeat": "d 7"}, http.MethodPost)
+	assert.NoError(t, err)
+	meeting := fmt.Sprint(ret["id"])
+	report := add("Разослать итоги созвона")
+	code, _ = dependency(report, meeting, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/task/done?id="+report, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, _ = requestStatus(t, "api/task/done?id="+meeting, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	task, err = postJSON("api/task?id="+meeting, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Now().AddDate(0, 0, 7).Format(`20060102`), task["date"])
+	task, err = postJSON("api/task?id="+report, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, task["blocked_by"])
+	code, _ = requestStatus(t, "api/task/done?id="+report, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Задача в колонке done на доске тоже не блокирует
+	review := add("Провести ревью")
+	merge := add("Влить изменения")
+	code, _ = dependency(merge, review, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/task/status?id="+review, map[string]any{"status": "done", "position": 0}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+	task, err = postJSON("api/task?id="+merge, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, task["blocked_by"])
+	code, _ = requestStatus(t, "api/task/done?id="+merge, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Отмена выполнения повторяющейся задачи возвращает снятые выполнением зависимости
+	ret, err = postJSON("api/task", map[string]any{"date": today, "title": "Ежедневная планёрка", "repeat": "d 1"}, http.MethodPost)
+	assert.NoError(t, err)
+	standup := fmt.Sprint(ret["id"])
+	notes := add("Записать договорённости")
+	code, _ = dependency(notes, standup, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	task, err = postJSON("api/task?id="+standup, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{notes}, task["blocks"])
+
+	code, _ = requestStatusAs(t, "depender", "api/task/done?id="+standup, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	task, err = postJSON("api/task?id="+notes, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, task["blocked_by"])
+
+	action, task := undoAs(t, "depender")
+	assert.Equal(t, "done", action)
+	assert.Equal(t, today, task["date"])
+	assert.Equal(t, []any{notes}, task["blocks"])
+	task, err = postJSON("api/task?id="+notes, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{standup}, task["blocked_by"])
+	code, _ = requestStatus(t, "api/task/done?id="+notes, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exportTask - задача из выгрузки в формате json или ndjson
+type exportTask struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Repeat  string   `json:"repeat"`
+	Tags    []string `json:"tags"`
+	History []struct {
+		Action string `json:"action"`
+	} `json:"history"`
+}
+
+func getExport(t *testing.T, format string) (*http.Response, []byte) {
+	resp, err := http.Get(getURL("api/export?format=" + format))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp, body
+}
+
+func TestExport(t *testing.T) {
+	// Заголовок уникален для запуска, чтобы отличить задачи теста от остальных
+	marker := fmt.Sprintf("Выгрузка %d", time.Now().UnixNano())
+	date := time.Now().AddDate(0, 0, 3).Format(`20060102`)
+
+	// Задач больше, чем возвращает список задач
+	const count = 60
+	ids := make(map[string]bool, count)
+	var lastID string
+	for i := 0; i < count; i++ {
+		ret, err := postJSON("api/task", map[string]any{
+			"date":   date,
+			"title":  marker,
+			"repeat": "d 2",
+			"tags":   []string{"выгрузка", "тест"},
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		lastID = fmt.Sprint(ret["id"])
+		ids[lastID] = true
+	}
+
+	// Для последней задачи в истории появится изменение
+	code, _ := requestStatus(t, "api/task?id="+lastID, map[string]any{"comment": "изменена"}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+
+	t.Run("csv", func(t *testing.T) {
+		resp, body := getExport(t, "csv")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/csv")
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+
+		records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+		assert.NoError(t, err)
+		if !assert.NotEmpty(t, records) {
+			return
+		}
+		assert.Equal(t, []string{"id", "date", "title", "comment", "repeat", "tags", "project_id", "priority",
+			"status", "estimate", "created_at", "deleted_at", "history"}, records[0])
+
+		found := 0
+		for _, rec := range records[1:] {
+			if rec[2] != marker {
+				continue
+			}
+			found++
+			assert.True(t, ids[rec[0]])
+			assert.Equal(t, "d 2", rec[4])
+			assert.Equal(t, "выгрузка тест", rec[5])
+
+			var history []map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(rec[12]), &history))
+			if rec[0] == lastID {
+				assert.Equal(t, "изменена", rec[3])
+				assert.Len(t, history, 2)
+			} else {
+				assert.Len(t, history, 1)
+			}
+		}
+		assert.Equal(t, count, found)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		resp, body := getExport(t, "ndjson")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/x-ndjson")
+
+		found := 0
+		scanner := bufio.NewScanner(strings.NewReader(string(body)))
+		for scanner.Scan() {
+			var task exportTask
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &task))
+			if task.Title != marker {
+				continue
+			}
+			found++
+			assert.Equal(t, []string{"выгрузка", "тест"}, task.Tags)
+			if assert.NotEmpty(t, task.History) {
+				assert.Equal(t, "create", task.History[0].Action)
+			}
+		}
+		assert.Equal(t, count, found)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		resp, body := getExport(t, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+		var m struct {
+			Tasks []exportTask `json:"tasks"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &m))
+		found := 0
+		for _, task := range m.Tasks {
+			if task.Title == marker {
+				found++
+				assert.Equal(t, "d 2", task.Repeat)
+			}
+		}
+		assert.Equal(t, count, found)
+	})
+
+	code, _ = requestStatus(t, "api/export?format=xml", nil, http.MethodGet)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
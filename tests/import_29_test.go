@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	utils "webtasksplannerexample/internal/utils"
+)
+
+// importResponse - ответ POST /api/import
+type importResponse struct {
+	DryRun  bool `json:"dry_run"`
+	Valid   int  `json:"valid"`
+	Created int  `json:"created"`
+	Failed  int  `json:"failed"`
+	Skipped int  `json:"skipped"`
+	Rows    []struct {
+		Row     int            `json:"row"`
+		OK      bool           `json:"ok"`
+		Skipped bool           `json:"skipped"`
+		ID      string         `json:"id"`
+		Task    map[string]any `json:"task"`
+		Error   string         `json:"error"`
+	} `json:"rows"`
+}
+
+func postImport(t *testing.T, query string, body string) (int, importResponse) {
+	var ret importResponse
+
+	resp, err := http.Post(getURL("api/import?"+query), "text/plain", strings.NewReader(body))
+	if !assert.NoError(t, err) {
+		return 0, ret
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	if resp.StatusCode == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(data, &ret))
+	}
+	return resp.StatusCode, ret
+}
+
+func TestParseTodoistDate(t *testing.T) {
+	now := time.Date(2024, 1, 26, 15, 30, 0, 0, time.UTC) // Пятница
+
+	tbl := []struct {
+		text   string
+		date   string
+		repeat string
+		err    bool
+	}{
+		{"", "", "", false},
+		{"2024-02-10", "20240210", "", false},
+		{"2024-02-10T09:00:00Z", "20240210", "", false},
+		{"tomorrow", "20240127", "", false},
+		{"every day", "", "d 1", false},
+		{"every 3 days at 9am", "", "d 3", false},
+		{"every! 2 days", "", "d 2", false},
+		{"every other week", "", "d 14", false},
+		{"every monday", "20240129", "w 1", false},
+		{"every mon, fri", "20240126", "w 1,5", false},
+		{"every weekday", "20240126", "w 1,2,3,4,5", false},
+		{"every month on the 5th", "20240205", "m 5", false},
+		{"every year", "", "y", false},
+		{"2024-02-31", "", "", true},
+		{"every 3 months", "", "", true},
+		{"every last day", "", "", true},
+		{"someday maybe", "", "", true},
+	}
+	for _, v := range tbl {
+		date, repeat, err := utils.ParseTodoistDate(now, v.text)
+		if v.err {
+			assert.Error(t, err, v.text)
+			continue
+		}
+		assert.NoError(t, err, v.text)
+		assert.Equal(t, v.date, date, v.text)
+		assert.Equal(t, v.repeat, repeat, v.text)
+	}
+}
+
+func TestImport(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	future := now.AddDate(0, 0, 5).Format(`20060102`)
+	marker := fmt.Sprintf("Импорт %d", now.UnixNano())
+
+	code, _ := postImport(t, "format=xml", "[]")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = postImport(t, "dry_run=maybe", "[]")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = postImport(t, "format=json", "{")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = postImport(t, "format=csv", "date,comment\n"+today+",без заголовка\n")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	t.Run("dry-run", func(t *testing.T) {
+		body := fmt.Sprintf(`{"tasks": [
+			{"id": "1", "date": %q, "title": %q, "repeat": "d 3", "tags": ["Импорт"], "history": [{"action": "create"}]},
+			{"date": %q, "title": ""},
+			{"date": %q, "title": %q, "repeat": "x 1"},
+			{"date": %q, "title": %q, "deleted_at": "2024-01-01T00:00:00Z"},
+			{"date": %q, "title": %q, "project_id": "999999"}
+		]}`, future, marker, future, future, marker, future, marker, future, marker)
+
+		code, ret := postImport(t, "dry_run=true", body)
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, ret.DryRun)
+		assert.Equal(t, 1, ret.Valid)
+		assert.Equal(t, 0, ret.Created)
+		assert.Equal(t, 3, ret.Failed)
+		assert.Equal(t, 1, ret.Skipped)
+		if !assert.Len(t, ret.Rows, 5) {
+			return
+		}
+		assert.True(t, ret.Rows[0].OK)
+		assert.Empty(t, ret.Rows[0].ID)
+		assert.Equal(t, future, ret.Rows[0].Task["date"])
+		assert.Equal(t, []any{"импорт"}, ret.Rows[0].Task["tags"])
+		assert.NotEmpty(t, ret.Rows[1].Error)
+		assert.NotEmpty(t, ret.Rows[2].Error)
+		assert.True(t, ret.Rows[3].Skipped)
+		assert.Equal(t, "проект не найден", ret.Rows[4].Error)
+
+		// В режиме dry_run задачи не создаются
+		_, data := getExport(t, "ndjson")
+		assert.NotContains(t, string(data), marker)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		past := now.AddDate(0, 0, -2).Format(`20060102`)
+		body := "title,date,repeat,tags,priority,estimate,status,history\n" +
+			marker + "," + past + ",,дача огород,2,30,in_progress,\"[{\"\"action\"\":\"\"create\"\"}]\"\n" +
+			marker + "," + future + ",w 8,,,,,\n" +
+			marker + "," + future + ",,,высокий,,,\n" +
+			marker + "," + future + ",d 1,,,,,\n"
+
+		code, ret := postImport(t, "format=csv", body)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, ret.Created)
+		assert.Equal(t, 2, ret.Failed)
+		if !assert.Len(t, ret.Rows, 4) {
+			return
+		}
+		assert.False(t, ret.Rows[1].OK)
+		assert.Contains(t, ret.Rows[2].Error, "priority")
+
+		task, err := postJSON("api/task?id="+ret.Rows[0].ID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		// Прошедшая дата разовой задачи заменяется сегодняшней, как при создании задачи
+		assert.Equal(t, today, task["date"])
+		assert.ElementsMatch(t, []any{"дача", "огород"}, task["tags"])
+		assert.Equal(t, float64(2), task["priority"])
+		assert.Equal(t, float64(30), task["estimate"])
+		assert.Equal(t, "in_progress", task["status"])
+
+		task, err = postJSON("api/task?id="+ret.Rows[3].ID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, "d 1", task["repeat"])
+
+		audit := getAudit(t, "task_id="+ret.Rows[3].ID)
+		if assert.Len(t, audit, 1) {
+			assert.Equal(t, "create", audit[0].Action)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		body := fmt.Sprintf("{\"date\": %q, \"title\": %q}\n\n{\"title\": 5}\n{\"title\": %q, \"comment\": \"без даты\"}\n",
+			future, marker, marker)
+
+		code, ret := postImport(t, "format=ndjson", body)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, ret.Created)
+		assert.Equal(t, 1, ret.Failed)
+		if !assert.Len(t, ret.Rows, 3) {
+			return
+		}
+		assert.Equal(t, 2, ret.Rows[1].Row)
+		assert.NotEmpty(t, ret.Rows[1].Error)
+		assert.Equal(t, today, ret.Rows[2].Task["date"])
+	})
+
+	t.Run("todoist", func(t *testing.T) {
+		body := "\ufeffTYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE,DURATION,DURATION_UNIT\n" +
+			"section,Работа,,,,,,,,,,\n" +
+			"task," + marker + " @Todoist @Созвон,Подготовить слайды,1,1,Аня,,every monday at 10am,en,Europe/Moscow,45,minute\n" +
+			"note,Не забыть проектор,,,,,,,,,,\n" +
+			"task," + marker + ",,4,1,Аня,,every 3 months,en,,,\n" +
+			"task," + marker + ",,4,2,Аня,,tomorrow,en,,1,day\n"
+
+		code, ret := postImport(t, "format=todoist", body)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, ret.Created)
+		assert.Equal(t, 1, ret.Failed)
+		assert.Equal(t, 2, ret.Skipped)
+		if !assert.Len(t, ret.Rows, 5) {
+			return
+		}
+		assert.True(t, ret.Rows[0].Skipped)
+		assert.Contains(t, ret.Rows[3].Error, "every 3 months")
+
+		task, err := postJSON("api/task?id="+ret.Rows[1].ID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, marker, task["title"])
+		assert.Equal(t, "Подготовить слайды", task["comment"])
+		assert.Equal(t, "w 1", task["repeat"])
+		assert.ElementsMatch(t, []any{"todoist", "созвон"}, task["tags"])
+		assert.Equal(t, float64(1), task["priority"])
+		assert.Equal(t, float64(45), task["estimate"])
+
+		task, err = postJSON("api/task?id="+ret.Rows[4].ID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task["date"])
+		assert.Nil(t, task["priority"])
+		assert.Equal(t, float64(24*60), task["estimate"])
+	})
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	dbutils "webtasksplannerexample/internal/db"
+	models "webtasksplannerexample/internal/models"
+	webserverutils "webtasksplannerexample/internal/webserver"
+)
+
+// Имя базы в памяти, с которой работает сервер, запущенный внутри процесса
+const memoryDBName = "tests"
+
+// Адрес сервера, запущенного внутри процесса
+var serverURL string
+
+// Каталог вложений и максимальный размер вложения сервера, запущенного внутри процесса
+var (
+	attachmentsDir string
+	attachmentMax  int64 = 1024
+)
+
+func TestMain(m *testing.M) {
+	if External {
+		os.Exit(m.Run())
+	}
+
+	store, err := dbutils.NewMemoryStore(memoryDBName)
+	if err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+
+	attachmentsDir, err = os.MkdirTemp("", "attachments")
+	if err != nil {
+		log.Fatal("Ошибка создания каталога вложений:", err)
+	}
+
+	conf := models.ServiceConfig{
+		HTTPWebDir:     "../web",
+		UndoWindow:     15 * time.Minute,
+		AttachmentsDir: attachmentsDir,
+		AttachmentMax:  attachmentMax,
+	}
+	server := httptest.NewServer(webserverutils.NewServer(conf, store).Router())
+	serverURL = server.URL
+
+	code := m.Run()
+
+	server.Close()
+	store.Close()
+	os.RemoveAll(attachmentsDir)
+	os.Exit(code)
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbutils "webtasksplannerexample/internal/db"
+)
+
+func TestMigrate(t *testing.T) {
+	db, err := dbutils.OpenDB(filepath.Join(t.TempDir(), "scheduler.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// База, созданная до появления миграций, уже содержит таблицу scheduler
+	_, err = db.Exec(`CREATE TABLE scheduler (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        date VARCHAR(8) NOT NULL,
+        title VARCHAR(64) NOT NULL,
+        comment TEXT CHECK(length(comment) <= 512),
+        repeat  VARCHAR(128)
+    )`)
+	assert.NoError(t, err)
+
+	statuses, err := dbutils.GetMigrationStatus(db, dbutils.DialectSQLite)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, statuses)
+	for _, st := range statuses {
+		assert.False(t, st.Applied, "миграция %d не должна считаться применённой", st.Version)
+	}
+
+	applied, err := dbutils.Migrate(db, dbutils.DialectSQLite)
+	assert.NoError(t, err)
+	assert.Equal(t, len(statuses), len(applied))
+
+	applied, err = dbutils.Migrate(db, dbutils.DialectSQLite)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err = dbutils.GetMigrationStatus(db, dbutils.DialectSQLite)
+	assert.NoError(t, err)
+	for _, st := range statuses {
+		assert.True(t, st.Applied, "миграция %d должна быть применена", st.Version)
+		assert.NotEmpty(t, st.AppliedAt)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchTask(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	code, ret := requestStatus(t, "api/projects", map[string]any{"name": "Частичные правки"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := fmt.Sprint(ret["id"])
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date": today, "title": "Старый заголовок", "comment": "Комментарий",
+		"tags": []string{"дом"}, "priority": 2, "project_id": projectID,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	// Меняется только заголовок, остальные поля сохраняются
+	code, ret = requestStatusAs(t, "patcher", "api/task?id="+id, map[string]any{"title": "Новый заголовок"}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Новый заголовок", ret["title"])
+	assert.Equal(t, "Комментарий", ret["comment"])
+	assert.Equal(t, today, ret["date"])
+	assert.Equal(t, []any{"дом"}, ret["tags"])
+	assert.Equal(t, float64(2), ret["priority"])
+	assert.Equal(t, projectID, ret["project_id"])
+
+	entries := getAudit(t, "task_id="+id+"&action=update")
+	assert.Len(t, entries, 1)
+	if len(entries) == 1 {
+		assert.Equal(t, "patcher", entries[0].Actor)
+		assert.Equal(t, "Старый заголовок", entries[0].Before["title"])
+	}
+
+	// null возвращает поле к значению по умолчанию
+	code, ret = requestStatus(t, "api/task?id="+id, map[string]any{"comment": nil, "tags": nil, "project_id": nil, "priority": 4}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, ret["comment"])
+	assert.Nil(t, ret["tags"])
+	assert.Nil(t, ret["project_id"])
+	assert.Equal(t, float64(4), ret["priority"])
+	assert.Equal(t, "Новый заголовок", ret["title"])
+
+	// При смене правила повторения дата пересчитывается
+	code, ret = requestStatus(t, "api/task?id="+id, map[string]any{"repeat": "w 5"}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+	friday := now.AddDate(0, 0, (int(time.Friday)-int(now.Weekday())+7)%7).Format(`20060102`)
+	assert.Equal(t, friday, ret["date"])
+	assert.Equal(t, "w 5", ret["repeat"])
+
+	// Явно переданная дата не пересчитывается
+	nextYear := now.AddDate(1, 0, 0).Format(`20060102`)
+	code, ret = requestStatus(t, "api/task?id="+id, map[string]any{"repeat": "d 3", "date": nextYear}, http.MethodPatch)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, nextYear, ret["date"])
+
+	// Результат проверяется по тем же правилам, что и в PUT
+	for _, patch := range []map[string]any{
+		{"title": ""},
+		{"title": nil},
+		{"date": "ooops"},
+		{"repeat": "q 1"},
+		{"priority": 7},
+		{"status": "archived"},
+		{"created_at": today},
+	} {
+		code, _ = requestStatus(t, "api/task?id="+id, patch, http.MethodPatch)
+		assert.Equal(t, http.StatusBadRequest, code, "%v", patch)
+	}
+	task, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Новый заголовок", task["title"])
+
+	// Правка заголовка не переносит просроченную задачу, разовую или повторяющуюся
+	db := openDB(t)
+	defer db.Close()
+	past := now.AddDate(0, 0, -10).Format(`20060102`)
+	for _, repeat := range []string{"", "d 3"} {
+		res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Просроченная', '', ?)`, past, repeat)
+		assert.NoError(t, err)
+		pastID, _ := res.LastInsertId()
+
+		code, ret = requestStatus(t, fmt.Sprintf("api/task?id=%d", pastID), map[string]any{"title": "Просроченная задача"}, http.MethodPatch)
+		assert.Equal(t, http.StatusOK, code, repeat)
+		assert.Equal(t, "Просроченная задача", ret["title"], repeat)
+		assert.Equal(t, past, ret["date"], repeat)
+	}
+
+	code, _ = requestStatus(t, "api/task?id=999999", map[string]any{"title": "Нет такой"}, http.MethodPatch)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = requestStatus(t, "api/task?id="+id, map[string]any{"project_id": "999999"}, http.MethodPatch)
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sortedTitles(t *testing.T, projectID, sort, order string) []string {
+	body, err := requestJSON(fmt.Sprintf("api/tasks?project=%s&sort=%s&order=%s", projectID, sort, order), nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m struct {
+		Tasks []struct {
+			Title string `json:"title"`
+		} `json:"tasks"`
+		Error string `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Empty(t, m.Error)
+
+	titles := []string{}
+	for _, task := range m.Tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestPrioritySort(t *testing.T) {
+	now := time.Now()
+
+	code, ret := requestStatus(t, "api/projects", map[string]any{"name": "Сортировка"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := fmt.Sprint(ret["id"])
+
+	// Повторение нужно, чтобы дата в будущем сохранилась при создании
+	add := func(title string, days int, priority int) string {
+		values := map[string]any{
+			"date":       now.AddDate(0, 0, days).Format(`20060102`),
+			"title":      title,
+			"repeat":     "d 30",
+			"project_id": projectID,
+		}
+		if priority != 0 {
+			values["priority"] = priority
+		}
+		ret, err := postJSON("api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		return fmt.Sprint(ret["id"])
+	}
+	add("Бета", 2, 3)
+	idAlpha := add("Альфа", 1, 0)
+	add("Гамма", 2, 1)
+	add("Дельта", 3, 1)
+
+	assert.Equal(t, []string{"Альфа", "Бета", "Гамма", "Дельта"}, sortedTitles(t, projectID, "", ""))
+	assert.Equal(t, []string{"Дельта", "Бета", "Гамма", "Альфа"}, sortedTitles(t, projectID, "date", "desc"))
+	assert.Equal(t, []string{"Гамма", "Дельта", "Бета", "Альфа"}, sortedTitles(t, projectID, "priority", "asc"))
+	assert.Equal(t, []string{"Альфа", "Бета", "Гамма", "Дельта"}, sortedTitles(t, projectID, "priority", "desc"))
+	assert.Equal(t, []string{"Альфа", "Бета", "Гамма", "Дельта"}, sortedTitles(t, projectID, "title", "asc"))
+	assert.Equal(t, []string{"Бета", "Альфа", "Гамма", "Дельта"}, sortedTitles(t, projectID, "created", "asc"))
+
+	ret, err := postJSON("api/task?id="+idAlpha, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["priority"])
+	assert.NotEmpty(t, ret["created_at"])
+
+	// Без поля priority приоритет при изменении сохраняется, 0 снимает его
+	ret, err = postJSON("api/task", map[string]any{
+		"id": idAlpha, "date": now.AddDate(0, 0, 1).Format(`20060102`), "title": "Альфа", "repeat": "d 30", "priority": 2,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task", map[string]any{
+		"id": idAlpha, "date": now.AddDate(0, 0, 1).Format(`20060102`), "title": "Альфа", "repeat": "d 30",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task?id="+idAlpha, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), ret["priority"])
+
+	for _, priority := range []int{-1, 5} {
+		ret, err = postJSON("api/task", map[string]any{"title": "Неверный приоритет", "priority": priority}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"])
+
+		ret, err = postJSON("api/task", map[string]any{
+			"id": idAlpha, "date": now.Format(`20060102`), "title": "Альфа", "priority": priority,
+		}, http.MethodPut)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"])
+	}
+
+	for _, query := range []string{"sort=id", "sort=date&order=up"} {
+		ret, err = postJSON("api/tasks?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"])
+	}
+}
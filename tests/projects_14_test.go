@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestStatus выполняет запрос к API и возвращает код ответа вместе с разобранным телом
+func requestStatus(t *testing.T, apipath string, values map[string]any, method string) (int, map[string]any) {
+	return requestStatusAs(t, "", apipath, values, method)
+}
+
+// requestStatusAs выполняет запрос к API от имени пользователя actor, если он указан
+func requestStatusAs(t *testing.T, actor string, apipath string, values map[string]any, method string) (int, map[string]any) {
+	var data []byte
+	if values != nil {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if actor != "" {
+		req.Header.Set("X-User", actor)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m), string(body))
+	return resp.StatusCode, m
+}
+
+func getProjectTasks(t *testing.T, projectID string) []map[string]any {
+	body, err := requestJSON("api/tasks?project="+projectID, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m struct {
+		Tasks []map[string]any `json:"tasks"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m.Tasks
+}
+
+func projectNames(t *testing.T, query string) []string {
+	_, ret := requestStatus(t, "api/projects"+query, nil, http.MethodGet)
+	names := []string{}
+	for _, p := range ret["projects"].([]any) {
+		names = append(names, p.(map[string]any)["name"].(string))
+	}
+	return names
+}
+
+func TestProjects(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	code, ret := requestStatus(t, "api/projects", map[string]any{"name": "  Релиз 2.0 ", "color": "#FFAA00"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	releaseID := fmt.Sprint(ret["id"])
+
+	code, ret = requestStatus(t, "api/projects", map[string]any{"name": "Дом"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	homeID := fmt.Sprint(ret["id"])
+
+	code, _ = requestStatus(t, "api/projects", map[string]any{"name": ""}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/projects", map[string]any{"name": "Цвет", "color": "red"}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, ret = requestStatus(t, "api/projects/"+releaseID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Релиз 2.0", ret["name"])
+	assert.Equal(t, "#ffaa00", ret["color"])
+	assert.Equal(t, false, ret["archived"])
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date": today, "title": "Собрать сборку", "project_id": releaseID,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	taskID := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date": today, "title": "Задача в несуществующем проекте", "project_id": "999999",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	tasks := getProjectTasks(t, releaseID)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, taskID, tasks[0]["id"])
+	assert.Equal(t, releaseID, tasks[0]["project_id"])
+	assert.Empty(t, getProjectTasks(t, homeID))
+
+	// Без поля project_id проект задачи при изменении сохраняется
+	ret, err = postJSON("api/task", map[string]any{"id": taskID, "date": today, "title": "Собрать релизную сборку"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Len(t, getProjectTasks(t, releaseID), 1)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id": taskID, "date": today, "title": "Собрать релизную сборку", "project_id": homeID,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Empty(t, getProjectTasks(t, releaseID))
+	assert.Len(t, getProjectTasks(t, homeID), 1)
+
+	// Архивный проект скрыт из списка, новые задачи в него не добавляются
+	code, _ = requestStatus(t, "api/projects/"+releaseID, map[string]any{"name": "Релиз 2.0", "archived": true}, http.MethodPut)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, projectNames(t, ""), "Релиз 2.0")
+	assert.Contains(t, projectNames(t, "?archived=true"), "Релиз 2.0")
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date": today, "title": "Задача в архивном проекте", "project_id": releaseID,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	// После удаления проекта задача остаётся без проекта
+	code, _ = requestStatus(t, "api/projects/"+homeID, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/projects/"+homeID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = requestStatus(t, "api/projects/"+homeID, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, task["project_id"])
+}
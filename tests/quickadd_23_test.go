@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	utils "webtasksplannerexample/internal/utils"
+)
+
+func TestQuickAdd(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	code, ret := requestStatus(t, "api/task/quick", map[string]any{"text": "Отчёт в пятницу каждую неделю #work !1"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Empty(t, ret["unparsed"])
+	friday := now.AddDate(0, 0, (int(time.Friday)-int(now.Weekday())+7)%7).Format(`20060102`)
+
+	task, err := postJSON("api/task?id="+fmt.Sprint(ret["id"]), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Отчёт", task["title"])
+	assert.Equal(t, friday, task["date"])
+	assert.Equal(t, "w 5", task["repeat"])
+	assert.Equal(t, []any{"work"}, task["tags"])
+	assert.Equal(t, float64(1), task["priority"])
+
+	code, ret = requestStatus(t, "api/task/quick", map[string]any{"text": "pay rent every month on the 5th"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	parsed := ret["task"].(map[string]any)
+	assert.Equal(t, "pay rent", parsed["title"])
+	assert.Equal(t, "m 5", parsed["repeat"])
+	date := fmt.Sprint(parsed["date"])
+	assert.Equal(t, "05", date[6:])
+	assert.GreaterOrEqual(t, date, today)
+
+	code, ret = requestStatus(t, "api/task/quick", map[string]any{"text": "Полить цветы каждые 3 дня"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	parsed = ret["task"].(map[string]any)
+	assert.Equal(t, "d 3", parsed["repeat"])
+	assert.Equal(t, today, parsed["date"])
+
+	// Неразобранные фрагменты возвращаются отдельно и не попадают в заголовок
+	code, ret = requestStatus(t, "api/task/quick", map[string]any{"text": "Купить молоко завтра !9 каждые 3 месяца"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, []any{"!9", "каждые 3 месяца"}, ret["unparsed"])
+	parsed = ret["task"].(map[string]any)
+	assert.Equal(t, "Купить молоко", parsed["title"])
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), parsed["date"])
+	assert.Nil(t, parsed["repeat"])
+
+	code, ret = requestStatus(t, "api/task/quick", map[string]any{"text": "Созвон по понедельникам и средам через 2 недели"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	parsed = ret["task"].(map[string]any)
+	assert.Equal(t, "Созвон", parsed["title"])
+	assert.Equal(t, "w 1,3", parsed["repeat"])
+	assert.Equal(t, now.AddDate(0, 0, 14).Format(`20060102`), parsed["date"])
+
+	code, _ = requestStatus(t, "api/task/quick", map[string]any{"text": "завтра #дом"}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestQuickAddPlainWords проверяет, что слова "every", "each", "каждый" в обычном тексте остаются в заголовке
+func TestQuickAddPlainWords(t *testing.T) {
+	now := time.Date(2024, 1, 26, 15, 30, 0, 0, time.UTC)
+
+	tbl := []struct {
+		text     string
+		title    string
+		repeat   string
+		unparsed []string
+	}{
+		{"read every book", "read every book", "", nil},
+		{"every other book on the shelf", "every other book on the shelf", "", nil},
+		{"buy 3 apples each 2 days ago", "buy 3 apples each 2 days ago", "", nil},
+		{"thank each", "thank each", "", nil},
+		{"Каждый охотник желает знать", "Каждый охотник желает знать", "", nil},
+		{"Проверять почту каждые 5 минут", "Проверять почту каждые 5 минут", "", nil},
+		{"Поблагодарить каждого участника", "Поблагодарить каждого участника", "", nil},
+		// Распознанные и явно неподдерживаемые правила по-прежнему разбираются
+		{"read every day", "read", "d 1", nil},
+		{"Каждые 2 недели стричь газон", "стричь газон", "d 14", nil},
+		{"Отчёт каждые 3 месяца", "Отчёт", "", []string{"каждые 3 месяца"}},
+	}
+	for _, v := range tbl {
+		quick := utils.ParseQuickTask(now, v.text)
+		assert.Equal(t, v.title, quick.Title, v.text)
+		assert.Equal(t, v.repeat, quick.Repeat, v.text)
+		assert.Equal(t, v.unparsed, quick.Unparsed, v.text)
+	}
+}
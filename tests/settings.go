@@ -5,3 +5,7 @@ var DBFile = "../dbdata/scheduler.db"
 var FullNextDate = true
 var Search = true
 var Token = ``
+
+// При External = false тесты запускают сервер внутри процесса (httptest) с базой в памяти,
+// при External = true проверяется заранее запущенный сервер на порту Port с базой DBFile
+var External = false
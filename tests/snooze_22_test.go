@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnooze(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "Позвонить"}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	code, ret := requestStatusAs(t, "snoozer", "api/task/snooze?id="+id+"&by=1d", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), ret["date"])
+
+	code, ret = requestStatusAs(t, "snoozer", "api/task/snooze?id="+id+"&by=3d", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, now.AddDate(0, 0, 4).Format(`20060102`), ret["date"])
+
+	entries := getAudit(t, "task_id="+id+"&action=snooze")
+	assert.Len(t, entries, 2)
+	if len(entries) == 2 {
+		assert.Equal(t, "snoozer", entries[0].Actor)
+		assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), entries[0].Before["date"])
+		assert.Equal(t, now.AddDate(0, 0, 4).Format(`20060102`), entries[0].After["date"])
+	}
+
+	// Перенос можно отменить
+	code, _ = requestStatusAs(t, "snoozer", "api/undo", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	task, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task["date"])
+
+	// У повторяющейся задачи переносится только текущее повторение
+	ret, err = postJSON("api/task", map[string]any{"date": today, "title": "Полить цветы", "repeat": "d 5"}, http.MethodPost)
+	assert.NoError(t, err)
+	repeatID := fmt.Sprint(ret["id"])
+
+	code, ret = requestStatus(t, "api/task/snooze?id="+repeatID+"&by=1w", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), ret["date"])
+	assert.Equal(t, "d 5", ret["repeat"])
+
+	code, ret = requestStatus(t, "api/task/snooze?id="+repeatID+"&by=next-monday", nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	week := now.AddDate(0, 0, 7)
+	nextMonday := week.AddDate(0, 0, 7-(int(week.Weekday())+6)%7)
+	assert.Equal(t, nextMonday.Format(`20060102`), ret["date"])
+	assert.Equal(t, time.Monday.String(), nextMonday.Weekday().String())
+
+	code, _ = requestStatus(t, "api/task/snooze?id="+repeatID+"&by=2d", nil, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/task/snooze?id="+repeatID, nil, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/task/snooze?id=999999&by=1d", nil, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+}
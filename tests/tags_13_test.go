@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taggedTask struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+func getTaggedTasks(t *testing.T, tag string) []taggedTask {
+	body, err := requestJSON("api/tasks?tag="+url.QueryEscape(tag), nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m struct {
+		Tasks []taggedTask `json:"tasks"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m.Tasks
+}
+
+func getTaggedTask(t *testing.T, id string) taggedTask {
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var task taggedTask
+	assert.NoError(t, json.Unmarshal(body, &task))
+	return task
+}
+
+func tagCount(t *testing.T, name string) int {
+	body, err := requestJSON("api/tags", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m struct {
+		Tags []struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		} `json:"tags"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	for _, tag := range m.Tags {
+		if tag.Name == name {
+			return tag.Count
+		}
+	}
+	return 0
+}
+
+func TestTags(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":  today,
+		"title": "Подготовить отчёт",
+		"tags":  []string{"Отчёты", "#срочно", "отчёты"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	id := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":  today,
+		"title": "Отправить отчёт",
+		"tags":  []string{"отчёты"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	otherID := fmt.Sprint(ret["id"])
+
+	task := getTaggedTask(t, id)
+	assert.Equal(t, []string{"отчёты", "срочно"}, task.Tags)
+
+	tasks := getTaggedTasks(t, "срочно")
+	assert.Len(t, tasks, 1)
+	tasks = getTaggedTasks(t, "#Отчёты")
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, 2, tagCount(t, "отчёты"))
+	assert.Equal(t, 1, tagCount(t, "срочно"))
+
+	// Без поля tags метки при изменении сохраняются
+	ret, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  today,
+		"title": "Подготовить квартальный отчёт",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	task = getTaggedTask(t, id)
+	assert.Equal(t, []string{"отчёты", "срочно"}, task.Tags)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  today,
+		"title": "Подготовить квартальный отчёт",
+		"tags":  []string{},
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	task = getTaggedTask(t, id)
+	assert.Empty(t, task.Tags)
+	assert.Equal(t, 0, tagCount(t, "срочно"))
+
+	// Задачи из корзины в подсчёт не входят
+	ret, err = postJSON("api/task?id="+otherID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, 0, tagCount(t, "отчёты"))
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":  today,
+		"title": "Метка с пробелом",
+		"tags":  []string{"две метки"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
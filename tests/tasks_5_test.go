@@ -107,6 +107,7 @@ func TestTasks(t *testing.T) {
 	tasks = getTasks(t, now.Format(`02.01.2006`))
 	fmt.Println(now.Format(`02.01.2006`))
 	fmt.Println(tasks)
-	assert.Equal(t, len(tasks), 1)
+	// Будущая дата сохраняется и у разовых задач, поэтому на эту дату три задачи
+	assert.Equal(t, len(tasks), 3)
 
 }
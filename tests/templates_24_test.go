@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	code, _ := requestStatus(t, "api/templates", map[string]any{"title": " ", "checklist": []string{"a"}}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/templates", map[string]any{"title": "Релиз", "repeat": "ooops"}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/templates", map[string]any{"title": "Релиз", "checklist": []string{"  "}}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/templates", map[string]any{"title": "Релиз", "comment": strings.Repeat("ы", 513)}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, ret := requestStatus(t, "api/templates", map[string]any{
+		"title":       " Выпуск релиза ",
+		"comment":     "По регламенту",
+		"tags":        []string{"#Release", "work"},
+		"checklist":   []string{"Собрать сборку", " Обновить changelog ", "Опубликовать"},
+		"date_offset": 3,
+	}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	templateID := fmt.Sprint(ret["id"])
+
+	code, ret = requestStatus(t, "api/templates/"+templateID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Выпуск релиза", ret["title"])
+	assert.Equal(t, []any{"release", "work"}, ret["tags"])
+	assert.Equal(t, []any{"Собрать сборку", "Обновить changelog", "Опубликовать"}, ret["checklist"])
+	assert.Equal(t, float64(3), ret["date_offset"])
+
+	code, ret = requestStatus(t, "api/templates", nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, ret["templates"])
+
+	// Задачи создаются с датой через date_offset дней, метками и чек-листом шаблона
+	code, ret = requestStatus(t, "api/templates/"+templateID+"/instantiate", nil, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Len(t, ret["ids"], 1)
+	taskID := fmt.Sprint(ret["ids"].([]any)[0])
+
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Выпуск релиза", task["title"])
+	assert.Equal(t, "По регламенту", task["comment"])
+	assert.Equal(t, time.Now().AddDate(0, 0, 3).Format(`20060102`), task["date"])
+	assert.Equal(t, []any{"release", "work"}, task["tags"])
+	assert.Equal(t, []string{"Собрать сборку:false", "Обновить changelog:false", "Опубликовать:false"}, checklistState(t, taskID))
+
+	entries := getAudit(t, "task_id="+taskID+"&action=create")
+	assert.Len(t, entries, 1)
+	// Задача записывается в журнал уже с чек-листом: она создаётся вместе с ним в одной транзакции
+	if len(entries) == 1 {
+		assert.Len(t, entries[0].After["checklist"], 3)
+	}
+
+	code, ret = requestStatus(t, "api/templates/"+templateID+"/instantiate",
+		map[string]any{"date": "20990101", "count": 2}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Len(t, ret["ids"], 2)
+	for _, id := range ret["ids"].([]any) {
+		task, err := postJSON("api/task?id="+fmt.Sprint(id), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, "20990104", task["date"])
+	}
+
+	code, _ = requestStatus(t, "api/templates/"+templateID+"/instantiate", map[string]any{"count": 100}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = requestStatus(t, "api/templates/"+templateID+"/instantiate", map[string]any{"project_id": "999999"}, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = requestStatus(t, "api/templates/"+templateID, map[string]any{"title": "Хотфикс", "comment": strings.Repeat("ы", 513)}, http.MethodPut)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, ret = requestStatus(t, "api/templates/"+templateID, map[string]any{"title": "Хотфикс", "repeat": "d 7"}, http.MethodPut)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Хотфикс", ret["title"])
+	assert.Empty(t, ret["checklist"])
+
+	code, _ = requestStatus(t, "api/templates/"+templateID, nil, http.MethodDelete)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = requestStatus(t, "api/templates/"+templateID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = requestStatus(t, "api/templates/"+templateID+"/instantiate", nil, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+}
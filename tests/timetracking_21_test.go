@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dbutils "webtasksplannerexample/internal/db"
+	"webtasksplannerexample/internal/models"
+)
+
+func TestTimeTracking(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+
+	code, ret := requestStatus(t, "api/projects", map[string]any{"name": "Учёт времени"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	projectID := fmt.Sprint(ret["id"])
+
+	ret, err := postJSON("api/task", map[string]any{"date": today, "title": "Отчёт", "estimate": 100000}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date": today, "title": "Отчёт", "project_id": projectID, "estimate": 90,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(ret["id"])
+
+	task, err := postJSON("api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(90), task["estimate"])
+
+	// У пользователя может идти только один таймер
+	code, _ = requestStatusAs(t, "alice", "api/task/timer/start?id="+taskID, nil, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = requestStatusAs(t, "alice", "api/task/timer/start?id="+taskID, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = requestStatusAs(t, "bob", "api/task/timer/stop?id="+taskID, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, code)
+	code, ret = requestStatusAs(t, "alice", "api/task/timer/stop?id="+taskID, nil, http.MethodPost)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, ret["ended_at"])
+	code, _ = requestStatusAs(t, "alice", "api/task/timer/start?id=999999", nil, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = requestStatusAs(t, "bob", "api/task/time?id="+taskID, map[string]any{"minutes": 0}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, ret = requestStatusAs(t, "bob", "api/task/time?id="+taskID, map[string]any{"minutes": 30, "date": "20260105"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "2026-01-05T00:00:00Z", ret["started_at"])
+	assert.Equal(t, float64(1800), ret["duration_seconds"])
+	code, _ = requestStatusAs(t, "bob", "api/task/time?id="+taskID, map[string]any{"minutes": 45, "date": "20260108"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = requestStatusAs(t, "bob", "api/task/time?id="+taskID, map[string]any{"minutes": 60, "date": "20260112"}, http.MethodPost)
+	assert.Equal(t, http.StatusCreated, code)
+
+	code, ret = requestStatus(t, "api/task/time?id="+taskID, nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, ret["entries"], 4)
+
+	// Отчёт за две недели января: записи сгруппированы по понедельникам
+	code, ret = requestStatus(t, "api/reports/time?project="+projectID+"&from=20260105&to=20260118", nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	projects := ret["projects"].([]any)
+	assert.Len(t, projects, 1)
+	project := projects[0].(map[string]any)
+	assert.Equal(t, projectID, project["project_id"])
+	assert.Equal(t, "Учёт времени", project["project_name"])
+	assert.Equal(t, float64(135), project["tracked_minutes"])
+	assert.Equal(t, float64(90), project["estimate_minutes"])
+
+	weeks := ret["weeks"].([]any)
+	assert.Len(t, weeks, 2)
+	assert.Equal(t, "20260105", weeks[0].(map[string]any)["week_start"])
+	assert.Equal(t, float64(75), weeks[0].(map[string]any)["tracked_minutes"])
+	assert.Equal(t, "20260112", weeks[1].(map[string]any)["week_start"])
+	assert.Equal(t, float64(60), weeks[1].(map[string]any)["tracked_minutes"])
+
+	code, ret = requestStatus(t, "api/reports/time?project="+projectID+"&from=20260105&to=20260111", nil, http.MethodGet)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, ret["weeks"], 1)
+
+	code, _ = requestStatus(t, "api/reports/time?from=ooops", nil, http.MethodGet)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestTimeReportOrder проверяет, что проекты в отчёте упорядочены по числовому идентификатору
+func TestTimeReportOrder(t *testing.T) {
+	store, err := dbutils.NewMemoryStore("time_report_order_test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+
+	now := time.Now().UTC()
+	track := func(projectID *string) {
+		id, err := store.Add(models.Task{Date: now.Format(`20060102`), Title: "Задача", ProjectID: projectID})
+		assert.NoError(t, err)
+		_, err = store.AddTimeEntry(models.TimeEntry{
+			TaskID:          strconv.FormatInt(id, 10),
+			Actor:           "alice",
+			StartedAt:       now.Add(-time.Hour).Format(time.RFC3339),
+			EndedAt:         now.Format(time.RFC3339),
+			DurationSeconds: 3600,
+		})
+		assert.NoError(t, err)
+	}
+
+	var ids []string
+	for i := 0; i < 10; i++ {
+		id, err := store.AddProject(models.Project{Name: fmt.Sprintf("Проект %d", i+1)})
+		assert.NoError(t, err)
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+	// Проекты 10 и 2: при сравнении строк "10" оказался бы раньше "2"
+	track(&ids[9])
+	track(&ids[1])
+	track(nil)
+
+	report, err := store.TimeReport(models.TimeReportFilter{})
+	assert.NoError(t, err)
+	order := []string{}
+	for _, project := range report.Projects {
+		order = append(order, project.ProjectID)
+	}
+	assert.Equal(t, []string{"", ids[1], ids[9]}, order)
+
+	weeks := []string{}
+	for _, week := range report.Weeks {
+		weeks = append(weeks, week.ProjectID)
+	}
+	assert.Equal(t, []string{"", ids[1], ids[9]}, weeks)
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func undoAs(t *testing.T, actor string) (string, map[string]any) {
+	ret := requestAs(t, actor, "api/undo", nil, http.MethodPost)
+	if e, ok := ret["error"]; ok {
+		return "", map[string]any{"error": e}
+	}
+	task, _ := ret["task"].(map[string]any)
+	return fmt.Sprint(ret["undone"]), task
+}
+
+func TestUndo(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	ret := requestAs(t, "carol", "api/task", map[string]any{
+		"date":   today,
+		"title":  "Полить цветы",
+		"repeat": "d 3",
+	}, http.MethodPost)
+	id := fmt.Sprint(ret["id"])
+
+	ret = requestAs(t, "carol", "api/task/done?id="+id, nil, http.MethodPost)
+	assert.Empty(t, ret)
+
+	// Ошибочно отмеченная повторяющаяся задача возвращается на прежнюю дату
+	action, task := undoAs(t, "carol")
+	assert.Equal(t, "done", action)
+	assert.Equal(t, id, task["id"])
+	assert.Equal(t, today, task["date"])
+
+	action, task = undoAs(t, "carol")
+	assert.Equal(t, "create", action)
+	assert.NotEmpty(t, task["deleted_at"])
+	notFoundTask(t, id)
+
+	_, task = undoAs(t, "carol")
+	assert.NotEmpty(t, task["error"], "отменять больше нечего")
+
+	// Нельзя отменить действие, если после него задачу изменил другой пользователь
+	ret = requestAs(t, "carol", "api/task", map[string]any{
+		"date":  today,
+		"title": "Купить хлеб",
+	}, http.MethodPost)
+	id = fmt.Sprint(ret["id"])
+	ret = requestAs(t, "dave", "api/task", map[string]any{
+		"id":    id,
+		"date":  today,
+		"title": "Купить батон",
+	}, http.MethodPut)
+	assert.Empty(t, ret)
+
+	_, task = undoAs(t, "carol")
+	assert.NotEmpty(t, task["error"], "ожидается конфликт")
+
+	action, task = undoAs(t, "dave")
+	assert.Equal(t, "update", action)
+	assert.Equal(t, "Купить хлеб", task["title"])
+
+	action, _ = undoAs(t, "carol")
+	assert.Equal(t, "create", action)
+
+	// Удаление отменяется восстановлением из корзины
+	ret = requestAs(t, "carol", "api/task", map[string]any{
+		"date":  today,
+		"title": "Позвонить маме",
+	}, http.MethodPost)
+	id = fmt.Sprint(ret["id"])
+	ret = requestAs(t, "carol", "api/task?id="+id, nil, http.MethodDelete)
+	assert.Empty(t, ret)
+
+	action, task = undoAs(t, "carol")
+	assert.Equal(t, "delete", action)
+	assert.Equal(t, "Позвонить маме", task["title"])
+	assert.Empty(t, task["deleted_at"])
+
+	ret = requestAs(t, "carol", "api/task?id="+id, nil, http.MethodGet)
+	assert.Equal(t, id, ret["id"])
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	utils "webtasksplannerexample/internal/utils"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	now := time.Date(2024, 1, 26, 15, 30, 0, 0, time.UTC)
+
+	tbl := []struct {
+		name   string
+		date   string
+		repeat string
+		want   string // Пустое значение - ожидается ошибка
+	}{
+		{"пустая дата", "", "", "20240126"},
+		{"пустая дата с повтором", "", "d 5", "20240126"},
+		{"сегодня", "20240126", "", "20240126"},
+		{"сегодня с повтором", "20240126", "d 5", "20240126"},
+		{"будущая", "20240210", "", "20240210"},
+		{"будущая с повтором", "20240210", "w 1", "20240210"},
+		{"прошедшая", "20240120", "", "20240126"},
+		{"прошедшая с повтором по дням", "20240120", "d 5", "20240130"},
+		{"прошедшая с ежегодным повтором", "20230301", "y", "20240301"},
+		{"прошедшая с повтором по дням недели", "20240101", "w 1,5", "20240129"},
+		{"неверный формат даты", "26.01.2024", "", ""},
+		{"несуществующая дата", "20240231", "", ""},
+		{"неверный повтор", "20240126", "x 1", ""},
+		{"неверный повтор у будущей даты", "20240210", "d 500", ""},
+	}
+	for _, v := range tbl {
+		got, err := utils.NormalizeDate(now, v.date, v.repeat)
+		if v.want == "" {
+			assert.Error(t, err, v.name)
+			continue
+		}
+		assert.NoError(t, err, v.name)
+		assert.Equal(t, v.want, got, v.name)
+	}
+}
+
+// TestCreateUpdateConsistency проверяет, что создание и изменение задачи одинаково обрабатывают даты
+func TestCreateUpdateConsistency(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	past := now.AddDate(0, 0, -3).Format(`20060102`)
+	future := now.AddDate(0, 0, 10).Format(`20060102`)
+
+	tbl := []struct {
+		date   string
+		repeat string
+		want   string
+	}{
+		{past, "", today},
+		{past, "d 7", now.AddDate(0, 0, 4).Format(`20060102`)},
+		{today, "", today},
+		{today, "d 7", today},
+		{future, "", future},
+		{future, "d 7", future},
+		{"", "", today},
+	}
+	for _, v := range tbl {
+		ret, err := postJSON("api/task", map[string]any{"date": v.date, "title": "Проверка дат", "repeat": v.repeat}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		id := fmt.Sprint(ret["id"])
+
+		task, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, v.want, task["date"], "создание %v", v)
+
+		date := v.date
+		if date == "" {
+			date = today
+		}
+		ret, err = postJSON("api/task", map[string]any{"id": id, "date": date, "title": "Проверка дат", "repeat": v.repeat}, http.MethodPut)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+
+		task, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Equal(t, v.want, task["date"], "изменение %v", v)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		ret, err := postJSON("api/task", map[string]any{"id": "1", "date": future, "title": "Проверка дат", "repeat": "d 500"}, method)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], method)
+	}
+}